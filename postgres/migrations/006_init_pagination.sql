@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE pull_requests SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE pull_requests ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX idx_users_team_created ON users(team_name, created_at, id);
+
+CREATE INDEX idx_pull_requests_created ON pull_requests(created_at, id);
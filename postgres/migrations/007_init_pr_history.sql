@@ -0,0 +1,28 @@
+CREATE TABLE pull_request_history (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    user_id TEXT,
+    old_user_id TEXT,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pull_request_history_pr ON pull_request_history(pull_request_id, id);
+
+INSERT INTO pull_request_history (pull_request_id, event, user_id, created_at)
+SELECT id, 'CREATED', author_id, created_at FROM pull_requests;
+
+INSERT INTO pull_request_history (pull_request_id, event, created_at)
+SELECT id, 'MERGED', merged_at FROM pull_requests WHERE status = 'MERGED' AND merged_at IS NOT NULL;
+
+--------------------------------------------------------------------
+--------------------------------------------------------------------
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_pull_requests_name_trgm ON pull_requests USING gin (name gin_trgm_ops);
+
+CREATE INDEX idx_pull_requests_author ON pull_requests(author_id);
+
+CREATE INDEX idx_pull_requests_status_created ON pull_requests(status, created_at, id);
@@ -0,0 +1,19 @@
+-- users outlive their team: deleting a team detaches its members and
+-- renaming it carries them along
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(name)
+    ON UPDATE CASCADE ON DELETE SET NULL;
+
+--------------------------------------------------------------------
+--------------------------------------------------------------------
+
+-- a user who authored PRs must never take them (and their history) along
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_author_id_fkey;
+
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id)
+    ON DELETE RESTRICT;
@@ -0,0 +1,11 @@
+CREATE TABLE user_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    from_team TEXT,
+    to_team TEXT,
+    policy TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_history_user ON user_history(user_id, id);
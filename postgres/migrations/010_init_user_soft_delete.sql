@@ -0,0 +1,4 @@
+-- deleted users keep their rows so authored PRs and review history survive
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_team_alive ON users(team_name, is_active) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- NULL means the user takes any number of open reviews
+ALTER TABLE users ADD COLUMN max_open_reviews INT CHECK (max_open_reviews >= 0);
@@ -0,0 +1,13 @@
+ALTER TABLE teams ADD COLUMN reviewer_policy TEXT NOT NULL DEFAULT 'allow_partial'
+    CHECK (reviewer_policy IN ('allow_partial', 'require_full'));
+
+-- review slots the selector could not fill yet; the filler job retries them
+ALTER TABLE pull_requests ADD COLUMN unfilled_slots INT NOT NULL DEFAULT 0 CHECK (unfilled_slots >= 0);
+
+UPDATE pull_requests AS pr
+SET unfilled_slots = GREATEST(0, 2 - (
+    SELECT COUNT(*) FROM users_pull_requests WHERE pull_requests_id = pr.id
+))
+WHERE pr.status = 'OPEN';
+
+CREATE INDEX idx_pull_requests_unfilled ON pull_requests(id) WHERE unfilled_slots > 0 AND status = 'OPEN';
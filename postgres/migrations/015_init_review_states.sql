@@ -0,0 +1,14 @@
+-- a declined assignment is replaced (or dropped) in the same transaction,
+-- so DECLINED never stays on a row; the decline is kept in pull_request_declines
+ALTER TABLE users_pull_requests
+    ADD COLUMN state TEXT NOT NULL DEFAULT 'PENDING'
+        CHECK (state IN ('PENDING', 'ACCEPTED', 'APPROVED', 'CHANGES_REQUESTED')),
+    ADD COLUMN state_updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- reviewers who declined a PR are never picked for it again
+CREATE TABLE pull_request_declines (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    declined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pull_request_id, user_id)
+);
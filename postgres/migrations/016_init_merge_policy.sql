@@ -0,0 +1,4 @@
+-- merge policy of the PR author's team; the defaults keep merging unconditional
+ALTER TABLE teams
+    ADD COLUMN min_approvals INT NOT NULL DEFAULT 0 CHECK (min_approvals >= 0),
+    ADD COLUMN block_on_changes_requested BOOLEAN NOT NULL DEFAULT false;
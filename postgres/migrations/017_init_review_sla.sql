@@ -0,0 +1,21 @@
+-- review SLA of the PR author's team, in hours; 0 turns a threshold off
+ALTER TABLE teams
+    ADD COLUMN remind_after_hours INT NOT NULL DEFAULT 0 CHECK (remind_after_hours >= 0),
+    ADD COLUMN escalate_after_hours INT NOT NULL DEFAULT 0 CHECK (escalate_after_hours >= 0),
+    ADD COLUMN escalation_action TEXT NOT NULL DEFAULT 'reassign'
+        CHECK (escalation_action IN ('reassign', 'escalate')),
+    ADD COLUMN lead_id TEXT REFERENCES users(id) ON DELETE SET NULL;
+
+-- reminded_at and escalated_at make the SLA job act once per assignment
+ALTER TABLE users_pull_requests
+    ADD COLUMN assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN reminded_at TIMESTAMPTZ,
+    ADD COLUMN escalated_at TIMESTAMPTZ;
+
+UPDATE users_pull_requests AS upr
+SET assigned_at = COALESCE(p.created_at, NOW())
+FROM pull_requests AS p
+WHERE p.id = upr.pull_requests_id;
+
+CREATE INDEX idx_users_pull_requests_assigned ON users_pull_requests(assigned_at)
+    WHERE escalated_at IS NULL;
@@ -0,0 +1,11 @@
+-- how each user wants to hear about their reviews; users without a row
+-- get the channels from NOTIFY_DEFAULT_CHANNELS and every event
+CREATE TABLE notification_settings (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    channels TEXT[] NOT NULL DEFAULT '{}',
+    -- event kinds the user wants; empty means all
+    events TEXT[] NOT NULL DEFAULT '{}',
+    email TEXT,
+    webhook_url TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
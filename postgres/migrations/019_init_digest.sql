@@ -0,0 +1,14 @@
+-- daily digest of the user's open reviews, sent once per local day at
+-- digest_time in digest_time_zone on the ISO weekdays in digest_days
+-- (empty means every day); digest_sent_on is the local date of the last one
+ALTER TABLE notification_settings
+    ADD COLUMN digest_enabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN digest_time TIME NOT NULL DEFAULT '09:00',
+    ADD COLUMN digest_time_zone TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN digest_days SMALLINT[] NOT NULL DEFAULT '{}',
+    -- NULL sends through NOTIFY_DIGEST_CHANNEL
+    ADD COLUMN digest_channel TEXT,
+    ADD COLUMN digest_sent_on DATE;
+
+CREATE INDEX idx_notification_settings_digest ON notification_settings(user_id)
+    WHERE digest_enabled;
@@ -0,0 +1,10 @@
+-- CODEOWNERS-style rules of a team, matched against the changed files of
+-- its members' PRs; the last matching rule (highest position) wins
+CREATE TABLE team_code_owners (
+    team_name TEXT NOT NULL REFERENCES teams(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    position INT NOT NULL,
+    pattern TEXT NOT NULL,
+    -- user ids; an empty list leaves the matching files unowned
+    owners TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (team_name, position)
+);
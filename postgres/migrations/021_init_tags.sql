@@ -0,0 +1,23 @@
+-- tags are user skills and PR labels at once; match_mode says how a PR
+-- label steers reviewer selection: 'prefer' ranks reviewers with the
+-- skill first, 'require' makes at least one of them mandatory
+CREATE TABLE tags (
+    name TEXT PRIMARY KEY,
+    description TEXT,
+    match_mode TEXT NOT NULL DEFAULT 'prefer' CHECK (match_mode IN ('prefer', 'require')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE user_skills (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL REFERENCES tags(name) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE INDEX idx_user_skills_tag ON user_skills(tag);
+
+CREATE TABLE pull_request_labels (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL REFERENCES tags(name) ON DELETE CASCADE,
+    PRIMARY KEY (pull_request_id, tag)
+);
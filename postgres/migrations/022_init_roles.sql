@@ -0,0 +1,9 @@
+-- seniority of a user; NULL until set and below every role
+ALTER TABLE users ADD COLUMN role TEXT CHECK (role IN ('junior', 'middle', 'senior', 'lead'));
+
+-- PRs of the team's members need at least min_role_reviewers reviewers
+-- whose role is min_reviewer_role or above; 0 turns the rule off
+ALTER TABLE teams
+    ADD COLUMN min_role_reviewers INT NOT NULL DEFAULT 0 CHECK (min_role_reviewers >= 0),
+    ADD COLUMN min_reviewer_role TEXT NOT NULL DEFAULT 'senior'
+        CHECK (min_reviewer_role IN ('junior', 'middle', 'senior', 'lead'));
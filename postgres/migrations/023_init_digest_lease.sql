@@ -0,0 +1,8 @@
+-- a digest is claimed for a while before it is sent and only marked sent
+-- (digest_sent_on) once delivered; a claim older than the lease is retried
+ALTER TABLE notification_settings ADD COLUMN digest_claimed_at TIMESTAMPTZ;
+
+-- zones postgres doesn't know can't be scheduled
+UPDATE notification_settings
+SET digest_time_zone = 'UTC'
+WHERE digest_time_zone NOT IN (SELECT name FROM pg_timezone_names);
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"os"
 	"pr/internal/app"
 	"pr/internal/config"
+	_ "time/tzdata" // digest time zones; the runtime image has no zoneinfo
 )
 
 func main() {
 	cfg := config.MustLoad()
 	app := app.NewBootstrap(cfg)
-	app.Run()
+
+	if err := app.Run(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -2,42 +2,137 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	stdhttp "net/http"
 	"os"
 	"os/signal"
 	"pr/internal/config"
+	"pr/internal/jobs"
+	"pr/internal/notify"
 	"pr/internal/repo"
+	idempotencypostgres "pr/internal/repo/idempotency/postgres"
+	lockpostgres "pr/internal/repo/lock/postgres"
+	"pr/internal/repo/pr"
 	prpostgres "pr/internal/repo/pr/postgres"
+	tagpostgres "pr/internal/repo/tag/postgres"
 	teampostgres "pr/internal/repo/team/postgres"
+	unavailabilitypostgres "pr/internal/repo/unavailability/postgres"
 	userpostgres "pr/internal/repo/user/postgres"
+	"pr/internal/scheduler"
 	"pr/internal/server/http"
 	"pr/pkg/database"
 	"pr/pkg/logger"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
 type Bootstrap struct {
-	log *slog.Logger
-	cfg *config.Config
+	log      *slog.Logger
+	cfg      *config.Config
+	notifier *notify.Notifier
+	workers  []*worker
 }
 
-func (b *Bootstrap) Run() {
-	repos, closeDB := b.initRepo()
-	defer closeDB()
+// Run starts the application and blocks until a termination signal arrives
+// or the HTTP server fails. A non-nil error means the application did not
+// stop cleanly and the process should exit with a non-zero code.
+func (b *Bootstrap) Run() error {
+	repos, closeDB, err := b.initRepo()
+	if err != nil {
+		return err
+	}
+
+	b.startWorker("notifier", b.notifier.Run)
+
+	sched := scheduler.New(repos.Lock, b.log)
+
+	sched.Add("idempotency-cleanup", time.Hour, jobs.IdempotencyCleanup(repos.Idempotency, b.log))
+
+	if reassign, _ := strconv.ParseBool(b.cfg.Jobs.ReassignOnUnavailable); reassign {
+		sched.Add("unavailability-handover", jobInterval(b.cfg.Jobs.UnavailabilityInterval, 60),
+			jobs.UnavailabilityHandover(repos.Unavailability, b.log))
+	}
+
+	sched.Add("reviewer-slot-filler", jobInterval(b.cfg.Jobs.FillSlotsInterval, 60),
+		jobs.FillReviewerSlots(repos.PR, b.log))
+	sched.Add("review-sla", jobInterval(b.cfg.Jobs.SLAInterval, 300), jobs.ReviewSLA(repos.PR, b.log))
+	sched.Add("review-digest", jobInterval(b.cfg.Jobs.DigestInterval, 60), jobs.Digests(repos.User, b.notifier, b.log))
+
+	b.startWorker("scheduler", sched.Run)
 
 	srv := http.NewRestAPI(&b.cfg.HTTP, repos, b.log)
-	srv.CreateServer()
-	defer srv.Close()
+	if err := srv.CreateServer(); err != nil {
+		b.log.Error("failed to start HTTP server", "error", err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = b.stopWorkers(ctx)
+
+		closeDB()
+
+		return err
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
-	<-stop
+
+	defer signal.Stop(stop)
+
+	var runErr error
+
+	select {
+	case sig := <-stop:
+		b.log.Info("received signal", "signal", sig.String())
+	case runErr = <-srv.Errors():
+		b.log.Error("HTTP server stopped unexpectedly", "error", runErr)
+	}
 
 	b.log.Info("shutting down...")
+
+	if err := b.shutdown(srv, closeDB); err != nil {
+		return errors.Join(runErr, err)
+	}
+
+	b.log.Info("shutdown complete")
+
+	return runErr
+}
+
+// drainer is the part of the HTTP server that shutdown needs.
+type drainer interface {
+	Shutdown(ctx context.Context, delay time.Duration) error
 }
 
-func (b *Bootstrap) initRepo() (*repo.Repo, func()) {
+// shutdown stops the components in dependency order: HTTP first (readiness
+// off, a delay for load balancers to notice, in-flight requests drained),
+// then background workers, then the database pool. All steps share one
+// drain timeout.
+func (b *Bootstrap) shutdown(srv drainer, closeDB func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), jobInterval(b.cfg.HTTP.ShutdownTimeout, 15))
+	defer cancel()
+
+	var errs []error
+
+	if err := srv.Shutdown(ctx, shutdownDelay(b.cfg.HTTP.ShutdownDelay)); err != nil {
+		b.log.Error("failed to drain HTTP server", "error", err)
+		errs = append(errs, fmt.Errorf("http shutdown: %w", err))
+	}
+
+	if err := b.stopWorkers(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	closeDB()
+
+	return errors.Join(errs...)
+}
+
+func (b *Bootstrap) initRepo() (*repo.Repo, func(), error) {
 	switch b.cfg.Storage.Type {
 	case "postgres":
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -49,25 +144,87 @@ func (b *Bootstrap) initRepo() (*repo.Repo, func()) {
 		if err != nil {
 			// добавить noop
 			b.log.Error("failed to connect to database", "error", err)
-			os.Exit(1)
 
-			return nil, func() {}
+			return nil, nil, err
 		}
 
-		teamDB := teampostgres.NewPrPostgres(conn, b.log)
-		userDB := userpostgres.NewPrPostgres(conn, b.log)
-		prDB := prpostgres.NewPrPostgres(conn, b.log)
+		exceed, _ := strconv.ParseBool(b.cfg.Assignment.ExceedCapacity)
+		policy := pr.AssignmentPolicy{ExceedCapacity: exceed}
+
+		// the notifier reads the users' settings, so the user repo is
+		// wrapped only once it exists
+		userStore := userpostgres.NewPrPostgres(conn, b.log, policy)
+		b.notifier = b.newNotifier(userStore)
+		userDB := notify.WrapUser(userStore, b.notifier)
+		teamDB := notify.WrapTeam(teampostgres.NewPrPostgres(conn, b.log, policy), b.notifier)
+		prDB := notify.WrapPR(prpostgres.NewPrPostgres(conn, b.log, policy), b.notifier)
+		idempotencyDB := idempotencypostgres.NewIdempotencyPostgres(conn, b.log)
+		unavailabilityDB := notify.WrapUnavailability(
+			unavailabilitypostgres.NewUnavailabilityPostgres(conn, b.log, policy), b.notifier)
+		lockDB := lockpostgres.NewLockPostgres(conn, b.log)
+		tagDB := tagpostgres.NewTagPostgres(conn, b.log)
+
+		return repo.NewRepo(teamDB, userDB, prDB, idempotencyDB, unavailabilityDB, lockDB, tagDB), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := lockDB.Close(ctx); err != nil {
+				b.log.Error("failed to close lock session", "error", err)
+			}
 
-		return repo.NewRepo(teamDB, userDB, prDB), func() {
 			conn.Close()
-		}
+		}, nil
 	default:
 		// добавить noop
 		b.log.Error("unsupported storage type", "type", b.cfg.Storage.Type)
-		os.Exit(1)
 
-		return nil, func() {}
+		return nil, nil, fmt.Errorf("unsupported storage type %q", b.cfg.Storage.Type)
+	}
+}
+
+func (b *Bootstrap) newNotifier(settings notify.SettingsSource) *notify.Notifier {
+	cfg := b.cfg.Notify
+
+	channels := map[string]notify.Channel{
+		notify.ChannelLog:     notify.NewLog(b.log),
+		notify.ChannelWebhook: notify.NewWebhook(cfg.WebhookURL, &stdhttp.Client{Timeout: 5 * time.Second}),
+	}
+
+	if cfg.SMTPAddr != "" {
+		channels[notify.ChannelEmail] = notify.NewEmail(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
 	}
+
+	var defaults []string
+
+	for _, name := range strings.Split(cfg.DefaultChannels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			defaults = append(defaults, name)
+		}
+	}
+
+	return notify.New(settings, channels, defaults, cfg.DigestChannel, b.log)
+}
+
+// jobInterval parses an interval or timeout in seconds, falling back to
+// def seconds when it is missing or not positive.
+func jobInterval(value string, def int) time.Duration {
+	seconds, _ := strconv.Atoi(value)
+	if seconds <= 0 {
+		seconds = def
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// shutdownDelay parses the readiness drain delay in seconds; 0 turns it
+// off and anything unparsable or negative falls back to 5 seconds.
+func shutdownDelay(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		seconds = 5
+	}
+
+	return time.Duration(seconds) * time.Second
 }
 
 func NewBootstrap(cfg *config.Config) *Bootstrap {
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"pr/internal/config"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// steps records the order in which the components stopped.
+type steps struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (s *steps) add(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names = append(s.names, name)
+}
+
+func (s *steps) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.names)
+}
+
+type serverStub struct {
+	steps *steps
+	err   error
+	delay time.Duration
+}
+
+func (s *serverStub) Shutdown(_ context.Context, delay time.Duration) error {
+	s.delay = delay
+	s.steps.add("http")
+
+	return s.err
+}
+
+func newTestBootstrap(shutdownTimeout string) *Bootstrap {
+	cfg := &config.Config{}
+	cfg.HTTP.ShutdownTimeout = shutdownTimeout
+	cfg.HTTP.ShutdownDelay = "0"
+
+	return &Bootstrap{log: slog.New(slog.NewTextHandler(io.Discard, nil)), cfg: cfg}
+}
+
+func (b *Bootstrap) startRecordingWorker(name string, s *steps) {
+	b.startWorker(name, func(ctx context.Context) {
+		<-ctx.Done()
+		s.add(name)
+	})
+}
+
+func TestShutdownOrder(t *testing.T) {
+	var s steps
+
+	b := newTestBootstrap("5")
+	b.startRecordingWorker("notifier", &s)
+	b.startRecordingWorker("scheduler", &s)
+
+	srv := &serverStub{steps: &s}
+
+	if err := b.shutdown(srv, func() { s.add("db") }); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	// the scheduler enqueues notifications, so it stops before the notifier
+	want := []string{"http", "scheduler", "notifier", "db"}
+	if got := s.list(); !slices.Equal(got, want) {
+		t.Errorf("stop order = %v, want %v", got, want)
+	}
+
+	if srv.delay != 0 {
+		t.Errorf("readiness delay = %v, want the configured 0", srv.delay)
+	}
+
+	if len(b.workers) != 0 {
+		t.Errorf("%d workers left after shutdown", len(b.workers))
+	}
+}
+
+func TestShutdownContinuesAfterHTTPError(t *testing.T) {
+	var s steps
+
+	b := newTestBootstrap("5")
+	b.startRecordingWorker("scheduler", &s)
+
+	drainErr := errors.New("drain timed out")
+
+	err := b.shutdown(&serverStub{steps: &s, err: drainErr}, func() { s.add("db") })
+	if !errors.Is(err, drainErr) {
+		t.Errorf("shutdown error = %v, want it to wrap %v", err, drainErr)
+	}
+
+	if got, want := s.list(), []string{"http", "scheduler", "db"}; !slices.Equal(got, want) {
+		t.Errorf("stop order = %v, want %v", got, want)
+	}
+}
+
+func TestShutdownClosesDBWhenWorkerHangs(t *testing.T) {
+	var s steps
+
+	b := newTestBootstrap("1")
+	release := make(chan struct{})
+
+	defer close(release)
+
+	b.startWorker("stuck", func(context.Context) { <-release })
+
+	err := b.shutdown(&serverStub{steps: &s}, func() { s.add("db") })
+	if err == nil || !strings.Contains(err.Error(), "stuck") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("shutdown error = %v, want the stuck worker's deadline", err)
+	}
+
+	if got, want := s.list(), []string{"http", "db"}; !slices.Equal(got, want) {
+		t.Errorf("stop order = %v, want %v", got, want)
+	}
+}
+
+func TestShutdownDelay(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    5 * time.Second,
+		"abc": 5 * time.Second,
+		"-1":  5 * time.Second,
+		"0":   0,
+		"10":  10 * time.Second,
+	}
+
+	for value, want := range tests {
+		if got := shutdownDelay(value); got != want {
+			t.Errorf("shutdownDelay(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -4,15 +4,61 @@ package apperrors
 import "net/http"
 
 type AppError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+	Status  int          `json:"-"`
+}
+
+// FieldError describes a single problem with one field of the request.
+// Field uses JSON names, with indexes for list items ("members[1].user_id").
+type FieldError struct {
+	Field   string `json:"field"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
-	Status  int    `json:"-"`
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
+const CodeValidationFailed = "VALIDATION_FAILED"
+
+func NewValidationError(details []FieldError) *AppError {
+	return &AppError{
+		Code:    CodeValidationFailed,
+		Message: "request validation failed",
+		Details: details,
+		Status:  http.StatusBadRequest,
+	}
+}
+
+const CodeMergeBlocked = "MERGE_BLOCKED"
+
+// NewMergeBlockedError lists the merge policy conditions the PR does not
+// meet; Field names the condition.
+func NewMergeBlockedError(details []FieldError) *AppError {
+	return &AppError{
+		Code:    CodeMergeBlocked,
+		Message: "pull request does not meet the team merge policy",
+		Details: details,
+		Status:  http.StatusConflict,
+	}
+}
+
+const CodeRequiredSkillUnavailable = "REQUIRED_SKILL_UNAVAILABLE"
+
+// NewRequiredSkillError lists the required PR labels no available reviewer
+// has as a skill; Field names the label.
+func NewRequiredSkillError(details []FieldError) *AppError {
+	return &AppError{
+		Code:    CodeRequiredSkillUnavailable,
+		Message: "no available reviewers cover the skills required by the PR labels",
+		Details: details,
+		Status:  http.StatusConflict,
+	}
+}
+
 var (
 	ErrTeamExists = &AppError{
 		Code:    "TEAM_EXISTS",
@@ -61,4 +107,118 @@ var (
 		Message: "no active replacement candidate in team",
 		Status:  http.StatusConflict,
 	}
+
+	ErrIdempotencyKeyReused = &AppError{
+		Code:    "IDEMPOTENCY_KEY_REUSED",
+		Message: "idempotency key was already used with a different request",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	ErrIdempotencyInProgress = &AppError{
+		Code:    "IDEMPOTENCY_IN_PROGRESS",
+		Message: "request with this idempotency key is still being processed",
+		Status:  http.StatusConflict,
+	}
+
+	ErrPreconditionFailed = &AppError{
+		Code:    "PRECONDITION_FAILED",
+		Message: "resource version does not match If-Match",
+		Status:  http.StatusPreconditionFailed,
+	}
+
+	ErrTeamHasOpenPRs = &AppError{
+		Code:    "TEAM_HAS_OPEN_PRS",
+		Message: "team members still author open pull requests",
+		Status:  http.StatusConflict,
+	}
+
+	ErrHasOpenReviews = &AppError{
+		Code:    "HAS_OPEN_REVIEWS",
+		Message: "user is still assigned to open pull requests",
+		Status:  http.StatusConflict,
+	}
+
+	ErrNoCapacity = &AppError{
+		Code:    "NO_CAPACITY",
+		Message: "every candidate reviewer is at their open review limit",
+		Status:  http.StatusConflict,
+	}
+
+	ErrNotEnoughReviewers = &AppError{
+		Code:    "NOT_ENOUGH_REVIEWERS",
+		Message: "the team requires a full set of reviewers but not enough candidates are available",
+		Status:  http.StatusConflict,
+	}
+
+	ErrReviewerIsAuthor = &AppError{
+		Code:    "REVIEWER_IS_AUTHOR",
+		Message: "the author can't review their own pull request",
+		Status:  http.StatusConflict,
+	}
+
+	ErrReviewerNotInTeam = &AppError{
+		Code:    "REVIEWER_NOT_IN_TEAM",
+		Message: "reviewer is not in the author's team or its fallback teams",
+		Status:  http.StatusConflict,
+	}
+
+	ErrUserInactive = &AppError{
+		Code:    "USER_INACTIVE",
+		Message: "user is inactive",
+		Status:  http.StatusConflict,
+	}
+
+	ErrAlreadyAssigned = &AppError{
+		Code:    "ALREADY_ASSIGNED",
+		Message: "reviewer is already assigned to this PR",
+		Status:  http.StatusConflict,
+	}
+
+	ErrTooManyReviewers = &AppError{
+		Code:    "TOO_MANY_REVIEWERS",
+		Message: "pull request already has the maximum number of reviewers",
+		Status:  http.StatusConflict,
+	}
+
+	ErrUserUnavailable = &AppError{
+		Code:    "USER_UNAVAILABLE",
+		Message: "user is within an unavailability window",
+		Status:  http.StatusConflict,
+	}
+
+	ErrInvalidReviewState = &AppError{
+		Code:    "INVALID_REVIEW_STATE",
+		Message: "review can't move to this state",
+		Status:  http.StatusConflict,
+	}
+
+	ErrReviewerDeclined = &AppError{
+		Code:    "REVIEWER_DECLINED",
+		Message: "user has declined to review this PR",
+		Status:  http.StatusConflict,
+	}
+
+	ErrTagExists = &AppError{
+		Code:    "TAG_EXISTS",
+		Message: "tag already exists",
+		Status:  http.StatusConflict,
+	}
+
+	ErrRoleRuleUnsatisfied = &AppError{
+		Code:    "ROLE_RULE_UNSATISFIED",
+		Message: "not enough available reviewers with the role the team requires",
+		Status:  http.StatusConflict,
+	}
+
+	ErrMembersHaveOpenPRs = &AppError{
+		Code:    "MEMBERS_HAVE_OPEN_PRS",
+		Message: "removed members still author open pull requests",
+		Status:  http.StatusConflict,
+	}
+
+	ErrMergeOverrideDisabled = &AppError{
+		Code:    "MERGE_OVERRIDE_DISABLED",
+		Message: "merge policy override is disabled",
+		Status:  http.StatusForbidden,
+	}
 )
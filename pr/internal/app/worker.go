@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+type worker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	name   string
+}
+
+// startWorker runs fn in the background until the application shuts down.
+// fn must return once its context is cancelled.
+func (b *Bootstrap) startWorker(name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &worker{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		name:   name,
+	}
+
+	go func() {
+		defer close(w.done)
+
+		fn(ctx)
+	}()
+
+	b.log.Info("worker started", "worker", name)
+	b.workers = append(b.workers, w)
+}
+
+// stopWorkers stops workers one by one in reverse start order, so a worker
+// never outlives the ones started before it.
+func (b *Bootstrap) stopWorkers(ctx context.Context) error {
+	for i := len(b.workers) - 1; i >= 0; i-- {
+		w := b.workers[i]
+		w.cancel()
+
+		select {
+		case <-w.done:
+			b.log.Info("worker stopped", "worker", w.name)
+		case <-ctx.Done():
+			return fmt.Errorf("worker %s did not stop in time: %w", w.name, ctx.Err())
+		}
+	}
+
+	b.workers = nil
+
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package codeowners matches changed file paths against CODEOWNERS-style
+// rules.
+//
+// Patterns follow the CODEOWNERS (gitignore) syntax without negation:
+//   - "*" and "?" match within one path segment, "[...]" is a class;
+//   - "**" as a whole segment matches any number of directories;
+//   - a pattern with a slash at the start or in the middle is anchored to
+//     the repository root, any other pattern matches at any depth;
+//   - a pattern naming a directory also matches everything under it; a
+//     trailing "/" or "/**" matches directories only and a trailing "/*"
+//     matches only the files directly inside.
+package codeowners
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrEmptyPattern = errors.New("pattern is empty")
+	ErrNegation     = errors.New("negated patterns are not supported")
+	ErrBadPattern   = path.ErrBadPattern
+)
+
+// Rule gives the files matching Pattern to Owners.
+type Rule struct {
+	Pattern string
+	Owners  []string
+}
+
+// ValidatePattern reports whether pattern can be matched.
+func ValidatePattern(pattern string) error {
+	switch {
+	case strings.Trim(pattern, "/") == "":
+		return ErrEmptyPattern
+	case strings.HasPrefix(pattern, "!"):
+		return ErrNegation
+	}
+
+	for _, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if seg == "" {
+			return ErrBadPattern
+		}
+
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether pattern matches the file at name, a slash
+// separated path relative to the repository root. Invalid patterns match
+// nothing.
+func Match(pattern, name string) bool {
+	if ValidatePattern(pattern) != nil {
+		return false
+	}
+
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return false
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	pp := strings.Split(trimmed, "/")
+
+	// "dir/**" is everything inside dir, but not dir itself
+	if len(pp) > 1 && pp[len(pp)-1] == "**" {
+		pp = pp[:len(pp)-1]
+		dirOnly = true
+	}
+
+	if !anchored {
+		pp = append([]string{"**"}, pp...)
+	}
+
+	np := strings.Split(name, "/")
+
+	// the pattern matches the file itself or one of its directories;
+	// "dir/*" stops at the direct children
+	shortest := len(np)
+	if pp[len(pp)-1] != "*" {
+		shortest = 1
+	}
+
+	longest := len(np)
+	if dirOnly {
+		longest--
+	}
+
+	for n := shortest; n <= longest; n++ {
+		if matchSegments(pp, np[:n]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pp, np []string) bool {
+	for len(pp) > 0 {
+		if pp[0] == "**" {
+			for i := 0; i <= len(np); i++ {
+				if matchSegments(pp[1:], np[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(np) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pp[0], np[0]); !ok {
+			return false
+		}
+
+		pp, np = pp[1:], np[1:]
+	}
+
+	return len(np) == 0
+}
+
+// OwnerOf returns the owners of the file at name. As in CODEOWNERS, the
+// last matching rule wins.
+func OwnerOf(rules []Rule, name string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if Match(rules[i].Pattern, name) {
+			return rules[i].Owners
+		}
+	}
+
+	return nil
+}
+
+// Owners ranks the owners of the changed files: owners of more files come
+// first, ties are broken by id so the order is the same on every call.
+func Owners(rules []Rule, files []string) []string {
+	counts := make(map[string]int)
+
+	for _, f := range files {
+		// an owner listed twice in a rule still owns the file once
+		for _, o := range slices.Compact(slices.Sorted(slices.Values(OwnerOf(rules, f)))) {
+			counts[o]++
+		}
+	}
+
+	owners := make([]string, 0, len(counts))
+	for o := range counts {
+		owners = append(owners, o)
+	}
+
+	slices.SortFunc(owners, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	return owners
+}
@@ -0,0 +1,140 @@
+package codeowners
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*", "README.md", true},
+		{"*", "web/src/app.ts", true},
+
+		{"*.go", "main.go", true},
+		{"*.go", "internal/app/app.go", true},
+		{"*.go", "main.go.orig", false},
+
+		{"README.md", "docs/README.md", true},
+		{"/README.md", "docs/README.md", false},
+		{"/README.md", "README.md", true},
+
+		{"docs", "docs/guide.md", true},
+		{"docs", "web/docs/guide.md", true},
+		{"docs", "docs", true},
+		{"docs/", "docs", false},
+		{"docs/", "web/docs/guide.md", true},
+		{"/docs/", "web/docs/guide.md", false},
+		{"/docs/", "docs/api/v1.md", true},
+
+		{"docs/*", "docs/guide.md", true},
+		{"docs/*", "docs/api/v1.md", false},
+		{"docs/*", "web/docs/guide.md", false},
+
+		{"docs/**", "docs/api/v1.md", true},
+		{"docs/**", "docs", false},
+		{"**/logs", "logs/a.log", true},
+		{"**/logs", "build/logs/a.log", true},
+		{"web/**/*.css", "web/app.css", true},
+		{"web/**/*.css", "web/src/ui/app.css", true},
+		{"web/**/*.css", "api/app.css", false},
+
+		{"internal/?pi/", "internal/api/handler.go", true},
+		{"internal/[ab]pi", "internal/cpi/handler.go", false},
+
+		{"web", "./web/app.ts", true},
+		{"web", "/web/app.ts", true},
+		{"web", "", false},
+
+		{"!web", "web/app.ts", false},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    error
+	}{
+		{"/web/**/*.ts", nil},
+		{"docs/", nil},
+		{"", ErrEmptyPattern},
+		{"/", ErrEmptyPattern},
+		{"!docs", ErrNegation},
+		{"web//src", ErrBadPattern},
+		{"web/[a", ErrBadPattern},
+	}
+
+	for _, tt := range tests {
+		if err := ValidatePattern(tt.pattern); !errors.Is(err, tt.want) {
+			t.Errorf("ValidatePattern(%q) = %v, want %v", tt.pattern, err, tt.want)
+		}
+	}
+}
+
+func TestOwnerOfLastRuleWins(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"lead"}},
+		{Pattern: "/web/", Owners: []string{"fe1", "fe2"}},
+		{Pattern: "/web/vendor/"},
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"go.mod", []string{"lead"}},
+		{"web/app.ts", []string{"fe1", "fe2"}},
+		// a later rule without owners leaves the file unowned
+		{"web/vendor/lib.js", nil},
+	}
+
+	for _, tt := range tests {
+		if got := OwnerOf(rules, tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("OwnerOf(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOwners(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*.go", Owners: []string{"be2", "be1"}},
+		{Pattern: "/web/", Owners: []string{"fe1", "fe1"}},
+		{Pattern: "/internal/notify/", Owners: []string{"be2"}},
+	}
+
+	files := []string{
+		"web/app.ts",
+		"web/app.css",
+		"internal/notify/email.go",
+		"internal/app/app.go",
+		"README.md",
+	}
+
+	// fe1 and be2 own two files each and sort by id, be1 owns one
+	want := []string{"be2", "fe1", "be1"}
+
+	for range 10 {
+		if got := Owners(rules, files); !slices.Equal(got, want) {
+			t.Fatalf("Owners = %v, want %v", got, want)
+		}
+	}
+
+	if got := Owners(rules, nil); len(got) != 0 {
+		t.Errorf("Owners of no files = %v, want none", got)
+	}
+
+	if got := Owners(nil, files); len(got) != 0 {
+		t.Errorf("Owners without rules = %v, want none", got)
+	}
+}
@@ -9,15 +9,51 @@ import (
 const PATH = "../.env"
 
 type Config struct {
-	Env     string `env:"ENV"`
-	HTTP    HTTP
-	Storage Storage
+	Env        string `env:"ENV"`
+	HTTP       HTTP
+	Storage    Storage
+	Jobs       Jobs
+	Assignment Assignment
+	Notify     Notify
 }
 
 type HTTP struct {
-	Addr        string `env:"ADDR"`
-	Timeout     string `env:"TIMEOUT"`
-	IdleTimeout string `env:"IDLE_TIMEOUT"`
+	Addr            string `env:"ADDR"`
+	Timeout         string `env:"TIMEOUT"`
+	IdleTimeout     string `env:"IDLE_TIMEOUT"`
+	ShutdownTimeout string `env:"SHUTDOWN_TIMEOUT" env-default:"15"`
+	// seconds between readiness going off and the listener closing
+	ShutdownDelay  string `env:"SHUTDOWN_DELAY" env-default:"5"`
+	IdempotencyTTL string `env:"IDEMPOTENCY_TTL" env-default:"86400"`
+	// lets merge requests bypass the team merge policy with override
+	AllowMergeOverride string `env:"ALLOW_MERGE_OVERRIDE" env-default:"false"`
+}
+
+type Jobs struct {
+	ReassignOnUnavailable  string `env:"REASSIGN_ON_UNAVAILABLE" env-default:"false"`
+	UnavailabilityInterval string `env:"UNAVAILABILITY_JOB_INTERVAL" env-default:"60"`
+	FillSlotsInterval      string `env:"FILL_SLOTS_JOB_INTERVAL" env-default:"60"`
+	SLAInterval            string `env:"SLA_JOB_INTERVAL" env-default:"300"`
+	DigestInterval         string `env:"DIGEST_JOB_INTERVAL" env-default:"60"`
+}
+
+type Assignment struct {
+	// assign reviewers beyond max_open_reviews instead of failing with NO_CAPACITY
+	ExceedCapacity string `env:"EXCEED_CAPACITY" env-default:"false"`
+}
+
+type Notify struct {
+	// comma separated channels for users who never saved notification settings
+	DefaultChannels string `env:"NOTIFY_DEFAULT_CHANNELS" env-default:"log"`
+	// the email channel is enabled only with a relay address
+	SMTPAddr     string `env:"SMTP_ADDR"`
+	SMTPFrom     string `env:"SMTP_FROM"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	// used for users without their own webhook_url
+	WebhookURL string `env:"NOTIFY_WEBHOOK_URL"`
+	// daily digests go here unless the user picked a channel
+	DigestChannel string `env:"NOTIFY_DIGEST_CHANNEL" env-default:"log"`
 }
 
 type Storage struct {
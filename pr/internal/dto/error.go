@@ -5,5 +5,6 @@ import apperrors "pr/internal/app/errors"
 // general
 
 type ErrorResponse struct {
-	Error apperrors.AppError `json:"error"`
+	Error     apperrors.AppError `json:"error"`
+	RequestID string             `json:"request_id,omitempty"`
 }
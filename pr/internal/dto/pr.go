@@ -5,10 +5,11 @@ import "time"
 // general
 
 type PR struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
-	Status   string `json:"status"`
+	ID       string   `json:"pull_request_id"`
+	Name     string   `json:"pull_request_name"`
+	AuthorID string   `json:"author_id"`
+	Status   string   `json:"status"`
+	Labels   []string `json:"labels,omitempty"`
 }
 
 type PRWithReviewers struct {
@@ -22,34 +23,149 @@ type PRWithReviewersAndMerge struct {
 	MergedAt          *time.Time `json:"mergedAt"`
 }
 
+type PRDetails struct {
+	PR
+	AssignedReviewers []string         `json:"assigned_reviewers"`
+	CreatedAt         *time.Time       `json:"createdAt"`
+	MergedAt          *time.Time       `json:"mergedAt"`
+	Reviews           []PRReview       `json:"reviews,omitempty"`
+	History           []PRHistoryEntry `json:"history,omitempty"`
+}
+
+// PRReview is the review state of one assigned reviewer.
+type PRReview struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	UserID    string    `json:"user_id"`
+	State     string    `json:"state"`
+}
+
+type PRHistoryEntry struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Event     string    `json:"event"`
+	UserID    string    `json:"user_id,omitempty"`
+	OldUserID string    `json:"old_user_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
 // requests
 
 type CreatePRRequest struct {
 	ID       string `json:"pull_request_id"`
 	Name     string `json:"pull_request_name"`
 	AuthorID string `json:"author_id"`
+	// ChangedFiles are repository paths; their code owners are preferred
+	// as reviewers.
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	// Labels are tag names; reviewers with matching skills are required
+	// or preferred depending on the mode of each tag.
+	Labels []string `json:"labels,omitempty"`
+}
+
+type GetPRRequest struct {
+	ID string
+}
+
+type ListPRsRequest struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	TeamName      string
+	AuthorID      string
+	ReviewerID    string
+	Status        string
+	Query         string
+	ListParams
 }
 
 type MergePRRequest struct {
 	ID string `json:"pull_request_id"`
+	// Override merges even if the team merge policy is not met.
+	Override bool `json:"override,omitempty"`
+}
+
+// ChangeReviewerRequest adds or removes one specific reviewer.
+type ChangeReviewerRequest struct {
+	PRID   string `json:"pull_request_id"`
+	UserID string `json:"user_id"`
 }
 
 type SwapPRReviewerRequest struct {
 	PRID      string `json:"pull_request_id"`
 	OldUserID string `json:"old_reviewer_id"`
+	// optional: assign this user instead of picking one at random
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+	// optional: users the random pick must skip
+	Exclude []string `json:"exclude,omitempty"`
+	// optional: free text recorded in the PR history
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetReviewStateRequest is sent by a reviewer to accept or decline the
+// review or to leave a verdict.
+type SetReviewStateRequest struct {
+	PRID   string `json:"pull_request_id"`
+	UserID string `json:"user_id"`
+	State  string `json:"state"`
+	// optional: free text recorded in the PR history
+	Reason string `json:"reason,omitempty"`
 }
 
 // responses
 
+// WarningCapacityExceeded marks a response where a reviewer was assigned
+// beyond their max_open_reviews because EXCEED_CAPACITY is enabled.
+const WarningCapacityExceeded = "CAPACITY_EXCEEDED"
+
+// FallbackReviewers lists the assigned reviewers that come from a
+// fallback team of the author's team. UnfilledSlots counts the reviewers
+// still missing; they are assigned later as candidates become available.
 type CreatePRResponse struct {
-	PR PRWithReviewers `json:"pr"`
+	PR                 PRWithReviewers `json:"pr"`
+	FallbackReviewers  []string        `json:"fallback_reviewers,omitempty"`
+	CodeOwnerReviewers []string        `json:"code_owner_reviewers,omitempty"`
+	SkillReviewers     []string        `json:"skill_reviewers,omitempty"`
+	Warnings           []string        `json:"warnings,omitempty"`
+	UnfilledSlots      int             `json:"unfilled_slots"`
+}
+
+type GetPRResponse struct {
+	PR PRDetails `json:"pr"`
+}
+
+type ListPRsResponse struct {
+	PullRequests []PRDetails `json:"pull_requests"`
+	Pagination
 }
 
 type MergePRResponse struct {
 	PR PRWithReviewersAndMerge `json:"pr"`
 }
 
+type ChangeReviewerResponse struct {
+	PR PRWithReviewers `json:"pr"`
+}
+
 type SwapPRReviewerResponse struct {
-	PR         PRWithReviewers `json:"pr"`
-	ReplacedBy string          `json:"replaced_by"`
+	PR                PRWithReviewers `json:"pr"`
+	ReplacedBy        string          `json:"replaced_by"`
+	FallbackReviewers []string        `json:"fallback_reviewers,omitempty"`
+	Warnings          []string        `json:"warnings,omitempty"`
+}
+
+// SetReviewStateResponse reports the replacement picked when the review
+// was declined; UnfilledSlots is 1 when nobody could take it over yet.
+type SetReviewStateResponse struct {
+	PR                PRWithReviewers `json:"pr"`
+	ReplacedBy        string          `json:"replaced_by,omitempty"`
+	FallbackReviewers []string        `json:"fallback_reviewers,omitempty"`
+	Warnings          []string        `json:"warnings,omitempty"`
+	UnfilledSlots     int             `json:"unfilled_slots,omitempty"`
+}
+
+// AssignWarnings lists the warnings to report for an assignment outcome.
+func AssignWarnings(capacityExceeded bool) []string {
+	if capacityExceeded {
+		return []string{WarningCapacityExceeded}
+	}
+
+	return nil
 }
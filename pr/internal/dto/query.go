@@ -0,0 +1,182 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"pr/internal/repo/page"
+)
+
+const (
+	sortCreatedAsc  = "created_at"
+	sortCreatedDesc = "-created_at"
+)
+
+// general
+
+// ListParams are the pagination and sorting parameters of list endpoints.
+type ListParams struct {
+	Cursor string
+	Limit  int
+	Desc   bool
+}
+
+type Pagination struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// requests
+
+func ParseGetTeamRequest(teamName string, q url.Values) (GetTeamRequest, error) {
+	var v validator
+
+	req := GetTeamRequest{
+		TeamName:   teamName,
+		ListParams: v.listParams(q, false),
+		IsActive:   v.boolParam(q, "is_active"),
+	}
+
+	req.validate(&v)
+
+	return req, v.err()
+}
+
+func ParseGetUserPRRequest(userID string, q url.Values) (GetUserPRRequest, error) {
+	var v validator
+
+	req := GetUserPRRequest{
+		UserID:        userID,
+		Status:        q.Get("status"),
+		AuthorID:      q.Get("author_id"),
+		CreatedAfter:  v.timeParam(q, "created_after"),
+		CreatedBefore: v.timeParam(q, "created_before"),
+		ListParams:    v.listParams(q, true),
+	}
+
+	req.validate(&v)
+
+	return req, v.err()
+}
+
+// ParseListPRsRequest also accepts older_than/newer_than durations
+// (e.g. "72h") as a shorthand for created_before/created_after.
+func ParseListPRsRequest(q url.Values, now time.Time) (ListPRsRequest, error) {
+	var v validator
+
+	req := ListPRsRequest{
+		TeamName:      q.Get("team_name"),
+		AuthorID:      q.Get("author_id"),
+		ReviewerID:    q.Get("reviewer_id"),
+		Status:        q.Get("status"),
+		Query:         q.Get("q"),
+		CreatedAfter:  v.timeParam(q, "created_after"),
+		CreatedBefore: v.timeParam(q, "created_before"),
+		ListParams:    v.listParams(q, true),
+	}
+
+	if age := v.durationParam(q, "older_than"); age != nil {
+		if req.CreatedBefore != nil {
+			v.add("older_than", FieldInvalidFormat, "cannot be combined with created_before")
+		} else {
+			t := now.Add(-*age)
+			req.CreatedBefore = &t
+		}
+	}
+
+	if age := v.durationParam(q, "newer_than"); age != nil {
+		if req.CreatedAfter != nil {
+			v.add("newer_than", FieldInvalidFormat, "cannot be combined with created_after")
+		} else {
+			t := now.Add(-*age)
+			req.CreatedAfter = &t
+		}
+	}
+
+	req.validate(&v)
+
+	return req, v.err()
+}
+
+// query parsing
+
+func (v *validator) listParams(q url.Values, defaultDesc bool) ListParams {
+	p := ListParams{
+		Cursor: q.Get("cursor"),
+		Desc:   defaultDesc,
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+
+		switch {
+		case err != nil:
+			v.add("limit", FieldInvalidFormat, "must be an integer")
+		case limit < 1 || limit > page.MaxLimit:
+			v.add("limit", FieldOutOfRange, fmt.Sprintf("must be between 1 and %d", page.MaxLimit))
+		default:
+			p.Limit = limit
+		}
+	}
+
+	switch q.Get("sort") {
+	case "":
+	case sortCreatedAsc:
+		p.Desc = false
+	case sortCreatedDesc:
+		p.Desc = true
+	default:
+		v.add("sort", FieldInvalidFormat,
+			fmt.Sprintf("must be %q or %q", sortCreatedAsc, sortCreatedDesc))
+	}
+
+	return p
+}
+
+func (v *validator) boolParam(q url.Values, name string) *bool {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil
+	}
+
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		v.add(name, FieldInvalidFormat, "must be true or false")
+		return nil
+	}
+
+	return &val
+}
+
+func (v *validator) durationParam(q url.Values, name string) *time.Duration {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil
+	}
+
+	val, err := time.ParseDuration(raw)
+	if err != nil || val < 0 {
+		v.add(name, FieldInvalidFormat, "must be a non-negative duration such as 48h")
+		return nil
+	}
+
+	return &val
+}
+
+func (v *validator) timeParam(q url.Values, name string) *time.Time {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil
+	}
+
+	val, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		v.add(name, FieldInvalidFormat, "must be an RFC 3339 timestamp")
+		return nil
+	}
+
+	return &val
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// general
+
+// Tag is a skill of users and a label of PRs. The mode of a label decides
+// whether a reviewer with the matching skill is required or only preferred.
+type Tag struct {
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Mode        string     `json:"mode"`
+}
+
+// requests
+
+// CreateTagRequest adds a tag to the catalog; a missing mode means prefer.
+type CreateTagRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Mode        string `json:"mode"`
+}
+
+// UpdateTagRequest replaces the description and mode of a tag.
+type UpdateTagRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Mode        string `json:"mode"`
+}
+
+type GetTagRequest struct {
+	Name string
+}
+
+type DeleteTagRequest struct {
+	Name string `json:"name"`
+}
+
+// responses
+
+type TagResponse struct {
+	Tag Tag `json:"tag"`
+}
+
+type ListTagsResponse struct {
+	Tags []Tag `json:"tags"`
+}
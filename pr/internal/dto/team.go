@@ -7,6 +7,37 @@ type Team struct {
 	Members  []User `json:"members"`
 }
 
+// TeamSettings are the reviewer selection settings of a team.
+type TeamSettings struct {
+	FallbackTeams  []string    `json:"fallback_teams"`
+	ReviewerPolicy string      `json:"reviewer_policy"`
+	MergePolicy    MergePolicy `json:"merge_policy"`
+	ReviewSLA      ReviewSLA   `json:"review_sla"`
+	RoleRule       RoleRule    `json:"role_rule"`
+}
+
+// RoleRule asks for at least min_reviewers reviewers whose role is
+// min_role or above; zero min_reviewers turns it off.
+type RoleRule struct {
+	MinReviewers int    `json:"min_reviewers"`
+	MinRole      string `json:"min_role"`
+}
+
+// MergePolicy gates merging PRs of the team's members.
+type MergePolicy struct {
+	MinApprovals            int  `json:"min_approvals"`
+	BlockOnChangesRequested bool `json:"block_on_changes_requested"`
+}
+
+// ReviewSLA sets when stale reviews are reminded of and escalated; zero
+// hours turn a threshold off.
+type ReviewSLA struct {
+	RemindAfterHours   int    `json:"remind_after_hours"`
+	EscalateAfterHours int    `json:"escalate_after_hours"`
+	EscalationAction   string `json:"escalation_action"`
+	LeadID             string `json:"lead_id,omitempty"`
+}
+
 // requests
 
 type CreateTeamRequest struct {
@@ -14,6 +45,64 @@ type CreateTeamRequest struct {
 	Members  []User `json:"members"`
 }
 
+type RemoveTeamMembersRequest struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids"`
+}
+
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+// PatchTeamRequest is the body of PATCH /api/v1/teams/{name}; the only
+// patchable field is the name.
+type PatchTeamRequest struct {
+	TeamName string `json:"team_name"`
+}
+
+type DeleteTeamRequest struct {
+	TeamName string `json:"team_name"`
+}
+
+// SetTeamSettingsRequest replaces all settings; a missing fallback_teams
+// clears the list, a missing reviewer_policy means allow_partial, a
+// missing merge_policy lets every PR merge, a missing review_sla turns
+// reminders and escalation off and a missing role_rule turns the role
+// rule off.
+type SetTeamSettingsRequest struct {
+	TeamName string `json:"team_name"`
+	TeamSettings
+}
+
+// CodeOwnerRule gives the files matching a CODEOWNERS-style pattern to
+// owners. An empty owners list leaves the files unowned.
+type CodeOwnerRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// SetCodeOwnersRequest replaces all code owner rules of the team. As in
+// CODEOWNERS, a later matching rule wins over an earlier one.
+type SetCodeOwnersRequest struct {
+	TeamName string          `json:"team_name"`
+	Rules    []CodeOwnerRule `json:"rules"`
+}
+
+type GetCodeOwnersRequest struct {
+	TeamName string
+}
+
+type GetTeamSettingsRequest struct {
+	TeamName string
+}
+
+type GetTeamRequest struct {
+	IsActive *bool
+	TeamName string
+	ListParams
+}
+
 // responses
 
 type CreateTeamResponse struct {
@@ -21,5 +110,33 @@ type CreateTeamResponse struct {
 }
 
 type GetTeamResponse struct {
-	Team Team `json:"team"`
+	Team       Team       `json:"team"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type TeamMembersResponse struct {
+	TeamName   string     `json:"team_name"`
+	Members    []User     `json:"members"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type RemoveTeamMembersResponse struct {
+	TeamName   string             `json:"team_name"`
+	Removed    []string           `json:"removed_user_ids"`
+	Reassigned []ReassignedReview `json:"reassigned"`
+}
+
+type CodeOwnersResponse struct {
+	TeamName string          `json:"team_name"`
+	Rules    []CodeOwnerRule `json:"rules"`
+}
+
+type TeamSettingsResponse struct {
+	TeamName string       `json:"team_name"`
+	Settings TeamSettings `json:"settings"`
+}
+
+type RenameTeamResponse struct {
+	TeamName         string `json:"team_name"`
+	PreviousTeamName string `json:"previous_team_name"`
 }
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// general
+
+type UnavailabilityWindow struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	UserID   string    `json:"user_id"`
+	Reason   string    `json:"reason,omitempty"`
+	ID       int64     `json:"id"`
+}
+
+// requests
+
+type ListUnavailabilityRequest struct {
+	UserID string
+}
+
+type CreateUnavailabilityRequest struct {
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	UserID   string     `json:"user_id"`
+	Reason   string     `json:"reason"`
+}
+
+type UpdateUnavailabilityRequest struct {
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	UserID   string     `json:"user_id"`
+	Reason   string     `json:"reason"`
+	ID       int64      `json:"id"`
+}
+
+type DeleteUnavailabilityRequest struct {
+	UserID string `json:"user_id"`
+	ID     int64  `json:"id"`
+}
+
+// responses
+
+type UnavailabilityResponse struct {
+	Window UnavailabilityWindow `json:"window"`
+}
+
+type ListUnavailabilityResponse struct {
+	UserID  string                 `json:"user_id"`
+	Windows []UnavailabilityWindow `json:"windows"`
+}
@@ -1,25 +1,110 @@
 package dto
 
+import "time"
+
 // general
 
+// MaxOpenReviews is omitted for users without a review limit.
 type User struct {
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
+	// Skills are tag names; a member sent without skills keeps the
+	// current ones and an empty list clears them
+	Skills []string `json:"skills"`
+	// Role is the member's seniority; a member sent without a role keeps
+	// the current one
+	Role     string `json:"role,omitempty"`
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
 }
 
 type UserWithTeam struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
-	TeamName string `json:"team_name"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	MaxOpenReviews *int       `json:"max_open_reviews,omitempty"`
+	UserID         string     `json:"user_id"`
+	Username       string     `json:"username"`
+	IsActive       bool       `json:"is_active"`
+	TeamName       string     `json:"team_name"`
 }
 
 // requests
 
+// SetUserStatusRequest requires is_active, so an empty body can't
+// deactivate the user by accident. The legacy route fills it in as false
+// before validation.
 type SetUserStatusRequest struct {
+	IsActive *bool  `json:"is_active"`
 	ID       string `json:"user_id"`
-	IsActive bool   `json:"is_active"`
+}
+
+type GetUserPRRequest struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UserID        string
+	Status        string
+	AuthorID      string
+	ListParams
+}
+
+type GetUserHistoryRequest struct {
+	UserID string
+}
+
+type DeleteUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type RestoreUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// SetUserSettingsRequest replaces the settings; a missing or null
+// max_open_reviews means no limit.
+type SetUserSettingsRequest struct {
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+	UserID         string `json:"user_id"`
+}
+
+// NotificationSettings say how the user hears about their reviews. An
+// empty events list means every event.
+type NotificationSettings struct {
+	Channels   []string       `json:"channels"`
+	Events     []string       `json:"events"`
+	Email      string         `json:"email,omitempty"`
+	WebhookURL string         `json:"webhook_url,omitempty"`
+	Digest     DigestSettings `json:"digest"`
+}
+
+// DigestSettings schedule the daily digest of open reviews. Time is the
+// local "HH:MM" time in the IANA time_zone; days are ISO weekdays, 1 is
+// Monday, and no days means every day. Without a channel the digest goes
+// through the service default.
+type DigestSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Time     string `json:"time"`
+	TimeZone string `json:"time_zone"`
+	Days     []int  `json:"days"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+type GetNotificationSettingsRequest struct {
+	UserID string
+}
+
+type GetDigestRequest struct {
+	UserID string
+}
+
+// SetNotificationSettingsRequest replaces the user's notification settings.
+type SetNotificationSettingsRequest struct {
+	UserID string `json:"user_id"`
+	NotificationSettings
+}
+
+type MoveUserTeamRequest struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+	Policy   string `json:"policy"`
 }
 
 // responses
@@ -29,6 +114,80 @@ type SetUserStatusResponse struct {
 }
 
 type GetUserPRResponse struct {
-	UserID string `json:"user_id"`
-	PR     []PR   `json:"pull_requests"`
+	UserID     string     `json:"user_id"`
+	PR         []PR       `json:"pull_requests"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type GetUserResponse struct {
+	User UserWithTeam `json:"user"`
+}
+
+// ReassignedReview has no ReplacedBy when the reviewer was removed
+// without a replacement.
+type ReassignedReview struct {
+	PRID       string `json:"pull_request_id"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+type DeleteUserResponse struct {
+	User       UserWithTeam       `json:"user"`
+	Reassigned []ReassignedReview `json:"reassigned"`
+}
+
+type RestoreUserResponse struct {
+	User UserWithTeam `json:"user"`
+}
+
+type SetUserSettingsResponse struct {
+	User UserWithTeam `json:"user"`
+}
+
+// NotificationSettingsResponse has Default set while the user has not
+// saved settings and gets the service defaults.
+type NotificationSettingsResponse struct {
+	UserID   string               `json:"user_id"`
+	Settings NotificationSettings `json:"settings"`
+	Default  bool                 `json:"default"`
+}
+
+// DigestReview is an open review in a digest; age_minutes is measured
+// against generated_at.
+type DigestReview struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	PRID       string    `json:"pull_request_id"`
+	PRName     string    `json:"pull_request_name"`
+	AuthorID   string    `json:"author_id"`
+	Age        string    `json:"age"`
+	AgeMinutes int64     `json:"age_minutes"`
+}
+
+// DigestResponse previews the user's digest as it would be sent now.
+type DigestResponse struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	UserID      string         `json:"user_id"`
+	TimeZone    string         `json:"time_zone"`
+	Reviews     []DigestReview `json:"reviews"`
+	Subject     string         `json:"subject"`
+	Text        string         `json:"text"`
+	HTML        string         `json:"html"`
+}
+
+type MoveUserTeamResponse struct {
+	User             UserWithTeam       `json:"user"`
+	PreviousTeamName string             `json:"previous_team_name"`
+	Reassigned       []ReassignedReview `json:"reassigned"`
+}
+
+type UserHistoryEntry struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Event     string    `json:"event"`
+	FromTeam  string    `json:"from_team,omitempty"`
+	ToTeam    string    `json:"to_team,omitempty"`
+	Policy    string    `json:"policy,omitempty"`
+}
+
+type GetUserHistoryResponse struct {
+	UserID  string             `json:"user_id"`
+	History []UserHistoryEntry `json:"history"`
 }
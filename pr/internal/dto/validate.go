@@ -0,0 +1,811 @@
+package dto
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/codeowners"
+)
+
+const (
+	MaxIDLength   = 64
+	MaxNameLength = 255
+	MaxURLLength  = 2048
+	MaxPathLength = 1024
+	// MaxChangedFiles bounds the changed files of a PR
+	MaxChangedFiles = 3000
+	// MaxCodeOwnerRules bounds the code owner rules of a team
+	MaxCodeOwnerRules = 1000
+	MaxTagLength      = 64
+	// MaxTags bounds the skills of a user and the labels of a PR
+	MaxTags = 20
+)
+
+// field error codes
+const (
+	FieldRequired      = "REQUIRED"
+	FieldInvalidFormat = "INVALID_FORMAT"
+	FieldTooLong       = "TOO_LONG"
+	FieldDuplicate     = "DUPLICATE"
+	FieldOutOfRange    = "OUT_OF_RANGE"
+)
+
+// IDs end up in URL paths, so they are restricted to a safe character set.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+
+// Tag names are lowercase so "Go" and "go" cannot both exist.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+type validator struct {
+	details []apperrors.FieldError
+}
+
+func (v *validator) add(field, code, msg string) {
+	v.details = append(v.details, apperrors.FieldError{
+		Field:   field,
+		Code:    code,
+		Message: msg,
+	})
+}
+
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.add(field, FieldRequired, "field is required")
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) maxLen(field, value string, limit int) bool {
+	if len([]rune(value)) > limit {
+		v.add(field, FieldTooLong, fmt.Sprintf("must be at most %d characters", limit))
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) id(field, value string) {
+	if !v.required(field, value) || !v.maxLen(field, value, MaxIDLength) {
+		return
+	}
+
+	if !idPattern.MatchString(value) {
+		v.add(field, FieldInvalidFormat,
+			"must start with a letter or digit and contain only letters, digits, '.', '_', '@' or '-'")
+	}
+}
+
+func (v *validator) name(field, value string) {
+	if !v.required(field, value) || !v.maxLen(field, value, MaxNameLength) {
+		return
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			v.add(field, FieldInvalidFormat, "must not contain control characters")
+			return
+		}
+	}
+}
+
+func (v *validator) maxOpenReviews(field string, value *int) {
+	if value != nil && *value < 0 {
+		v.add(field, FieldOutOfRange, "must not be negative")
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.details) == 0 {
+		return nil
+	}
+
+	return apperrors.NewValidationError(v.details)
+}
+
+func (r *CreateTeamRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	seen := make(map[string]int, len(r.Members))
+
+	for i, m := range r.Members {
+		field := fmt.Sprintf("members[%d]", i)
+
+		v.id(field+".user_id", m.UserID)
+		v.name(field+".username", m.Username)
+		v.maxOpenReviews(field+".max_open_reviews", m.MaxOpenReviews)
+		v.tags(field+".skills", m.Skills)
+
+		if m.Role != "" {
+			v.role(field+".role", m.Role)
+		}
+
+		if m.UserID == "" {
+			continue
+		}
+
+		if first, ok := seen[m.UserID]; ok {
+			v.add(field+".user_id", FieldDuplicate,
+				fmt.Sprintf("duplicates members[%d].user_id", first))
+
+			continue
+		}
+
+		seen[m.UserID] = i
+	}
+
+	return v.err()
+}
+
+func (r *RemoveTeamMembersRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	if len(r.UserIDs) == 0 {
+		v.add("user_ids", FieldRequired, "at least one user_id is required")
+	}
+
+	seen := make(map[string]int, len(r.UserIDs))
+
+	for i, id := range r.UserIDs {
+		field := fmt.Sprintf("user_ids[%d]", i)
+
+		v.id(field, id)
+
+		if first, ok := seen[id]; ok {
+			v.add(field, FieldDuplicate, fmt.Sprintf("duplicates user_ids[%d]", first))
+
+			continue
+		}
+
+		seen[id] = i
+	}
+
+	return v.err()
+}
+
+func (r *RenameTeamRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+	v.name("new_team_name", r.NewTeamName)
+
+	if r.NewTeamName != "" && r.NewTeamName == r.TeamName {
+		v.add("new_team_name", FieldInvalidFormat, "must differ from team_name")
+	}
+
+	return v.err()
+}
+
+func (r *DeleteTeamRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	return v.err()
+}
+
+func (r *GetTeamRequest) Validate() error {
+	var v validator
+
+	r.validate(&v)
+
+	return v.err()
+}
+
+func (r *GetTeamRequest) validate(v *validator) {
+	v.name("team_name", r.TeamName)
+}
+
+func (r *SetTeamSettingsRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	seen := make(map[string]int, len(r.FallbackTeams))
+
+	for i, name := range r.FallbackTeams {
+		field := fmt.Sprintf("fallback_teams[%d]", i)
+
+		v.name(field, name)
+
+		if name == r.TeamName {
+			v.add(field, FieldInvalidFormat, "a team can't be its own fallback")
+			continue
+		}
+
+		if first, ok := seen[name]; ok {
+			v.add(field, FieldDuplicate, fmt.Sprintf("duplicates fallback_teams[%d]", first))
+			continue
+		}
+
+		seen[name] = i
+	}
+
+	switch r.ReviewerPolicy {
+	case "", "allow_partial", "require_full":
+	default:
+		v.add("reviewer_policy", FieldInvalidFormat, "must be allow_partial or require_full")
+	}
+
+	// only assigned reviewers approve, so more than two approvals can't be met
+	if r.MergePolicy.MinApprovals < 0 || r.MergePolicy.MinApprovals > 2 {
+		v.add("merge_policy.min_approvals", FieldOutOfRange, "must be between 0 and 2")
+	}
+
+	v.reviewSLA(r.ReviewSLA)
+
+	// the rule counts assigned reviewers, so it can ask for two at most
+	if r.RoleRule.MinReviewers < 0 || r.RoleRule.MinReviewers > 2 {
+		v.add("role_rule.min_reviewers", FieldOutOfRange, "must be between 0 and 2")
+	}
+
+	if r.RoleRule.MinRole != "" {
+		v.role("role_rule.min_role", r.RoleRule.MinRole)
+	}
+
+	return v.err()
+}
+
+func (v *validator) role(field, value string) {
+	switch value {
+	case "junior", "middle", "senior", "lead":
+	default:
+		v.add(field, FieldInvalidFormat, "must be one of junior, middle, senior, lead")
+	}
+}
+
+func (v *validator) reviewSLA(sla ReviewSLA) {
+	if sla.RemindAfterHours < 0 {
+		v.add("review_sla.remind_after_hours", FieldOutOfRange, "must not be negative")
+	}
+
+	if sla.EscalateAfterHours < 0 {
+		v.add("review_sla.escalate_after_hours", FieldOutOfRange, "must not be negative")
+	}
+
+	if sla.RemindAfterHours > 0 && sla.EscalateAfterHours > 0 && sla.EscalateAfterHours <= sla.RemindAfterHours {
+		v.add("review_sla.escalate_after_hours", FieldOutOfRange, "must be greater than remind_after_hours")
+	}
+
+	switch sla.EscalationAction {
+	case "", "reassign":
+	case "escalate":
+		if sla.LeadID == "" {
+			v.add("review_sla.lead_id", FieldRequired, "required when escalation_action is escalate")
+		}
+	default:
+		v.add("review_sla.escalation_action", FieldInvalidFormat, "must be reassign or escalate")
+	}
+
+	if sla.LeadID != "" {
+		v.id("review_sla.lead_id", sla.LeadID)
+	}
+}
+
+func (r *SetCodeOwnersRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	if len(r.Rules) > MaxCodeOwnerRules {
+		v.add("rules", FieldOutOfRange, fmt.Sprintf("must have at most %d rules", MaxCodeOwnerRules))
+		return v.err()
+	}
+
+	for i, rule := range r.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+
+		if v.path(field+".pattern", rule.Pattern) {
+			if err := codeowners.ValidatePattern(rule.Pattern); err != nil {
+				v.add(field+".pattern", FieldInvalidFormat, err.Error())
+			}
+		}
+
+		seen := make(map[string]int, len(rule.Owners))
+
+		for j, owner := range rule.Owners {
+			ownerField := fmt.Sprintf("%s.owners[%d]", field, j)
+
+			v.id(ownerField, owner)
+
+			if first, ok := seen[owner]; ok {
+				v.add(ownerField, FieldDuplicate, fmt.Sprintf("duplicates %s.owners[%d]", field, first))
+				continue
+			}
+
+			seen[owner] = j
+		}
+	}
+
+	return v.err()
+}
+
+func (r *GetCodeOwnersRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	return v.err()
+}
+
+func (r *GetTeamSettingsRequest) Validate() error {
+	var v validator
+
+	v.name("team_name", r.TeamName)
+
+	return v.err()
+}
+
+func (r *SetUserStatusRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.ID)
+
+	if r.IsActive == nil {
+		v.add("is_active", FieldRequired, "field is required")
+	}
+
+	return v.err()
+}
+
+func (r *GetUserHistoryRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *DeleteUserRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *RestoreUserRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *SetUserSettingsRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+	v.maxOpenReviews("max_open_reviews", r.MaxOpenReviews)
+
+	return v.err()
+}
+
+func (r *GetNotificationSettingsRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *SetNotificationSettingsRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	v.oneOfEach("channels", r.Channels, "email", "webhook", "log")
+	v.oneOfEach("events", r.Events, "assigned", "reassigned", "unassigned", "merged", "reminder",
+		"escalated")
+
+	if r.Email == "" {
+		if slices.Contains(r.Channels, "email") {
+			v.add("email", FieldRequired, "required for the email channel")
+		}
+	} else if v.maxLen("email", r.Email, MaxNameLength) {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			v.add("email", FieldInvalidFormat, "must be an email address")
+		}
+	}
+
+	if r.WebhookURL != "" && v.maxLen("webhook_url", r.WebhookURL, MaxURLLength) {
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add("webhook_url", FieldInvalidFormat, "must be an http or https URL")
+		}
+	}
+
+	v.digest("digest", r.Digest)
+
+	if r.Digest.Channel == "email" && r.Email == "" && !slices.Contains(r.Channels, "email") {
+		v.add("email", FieldRequired, "required for the email digest channel")
+	}
+
+	return v.err()
+}
+
+func (v *validator) digest(field string, d DigestSettings) {
+	if d.Time != "" {
+		if _, err := time.Parse("15:04", d.Time); err != nil {
+			v.add(field+".time", FieldInvalidFormat, "must be a time in HH:MM format")
+		}
+	}
+
+	// "Local" would mean the server's zone, which Postgres does not know
+	if d.TimeZone != "" && v.maxLen(field+".time_zone", d.TimeZone, MaxNameLength) {
+		if _, err := time.LoadLocation(d.TimeZone); err != nil || d.TimeZone == "Local" {
+			v.add(field+".time_zone", FieldInvalidFormat, "must be an IANA time zone such as Europe/Berlin")
+		}
+	}
+
+	seen := make(map[int]int, len(d.Days))
+
+	for i, day := range d.Days {
+		dayField := fmt.Sprintf("%s.days[%d]", field, i)
+
+		if day < 1 || day > 7 {
+			v.add(dayField, FieldOutOfRange, "must be an ISO weekday from 1 (Monday) to 7 (Sunday)")
+			continue
+		}
+
+		if first, ok := seen[day]; ok {
+			v.add(dayField, FieldDuplicate, fmt.Sprintf("duplicates %s.days[%d]", field, first))
+			continue
+		}
+
+		seen[day] = i
+	}
+
+	switch d.Channel {
+	case "", "email", "webhook", "log":
+	default:
+		v.add(field+".channel", FieldInvalidFormat, "must be email, webhook or log")
+	}
+}
+
+func (r *GetDigestRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+// oneOfEach checks that every item of a list is one of allowed and listed once.
+func (v *validator) oneOfEach(field string, items []string, allowed ...string) {
+	seen := make(map[string]int, len(items))
+
+	for i, item := range items {
+		itemField := fmt.Sprintf("%s[%d]", field, i)
+
+		if !slices.Contains(allowed, item) {
+			v.add(itemField, FieldInvalidFormat, "must be one of "+strings.Join(allowed, ", "))
+			continue
+		}
+
+		if first, ok := seen[item]; ok {
+			v.add(itemField, FieldDuplicate, fmt.Sprintf("duplicates %s[%d]", field, first))
+			continue
+		}
+
+		seen[item] = i
+	}
+}
+
+func (r *MoveUserTeamRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+	v.name("team_name", r.TeamName)
+
+	switch r.Policy {
+	case "keep", "reassign", "fail":
+	case "":
+		v.add("policy", FieldRequired, "field is required")
+	default:
+		v.add("policy", FieldInvalidFormat, "must be keep, reassign or fail")
+	}
+
+	return v.err()
+}
+
+func (r *GetUserPRRequest) Validate() error {
+	var v validator
+
+	r.validate(&v)
+
+	return v.err()
+}
+
+func (r *GetUserPRRequest) validate(v *validator) {
+	v.id("user_id", r.UserID)
+
+	switch r.Status {
+	case "", "OPEN", "MERGED":
+	default:
+		v.add("status", FieldInvalidFormat, "must be OPEN or MERGED")
+	}
+
+	if r.AuthorID != "" {
+		v.id("author_id", r.AuthorID)
+	}
+
+	if r.CreatedAfter != nil && r.CreatedBefore != nil && !r.CreatedAfter.Before(*r.CreatedBefore) {
+		v.add("created_before", FieldOutOfRange, "must be later than created_after")
+	}
+}
+
+func (r *CreatePRRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.ID)
+	v.name("pull_request_name", r.Name)
+	v.id("author_id", r.AuthorID)
+
+	if len(r.ChangedFiles) > MaxChangedFiles {
+		v.add("changed_files", FieldOutOfRange, fmt.Sprintf("must list at most %d files", MaxChangedFiles))
+	} else {
+		for i, f := range r.ChangedFiles {
+			v.path(fmt.Sprintf("changed_files[%d]", i), f)
+		}
+	}
+
+	v.tags("labels", r.Labels)
+
+	return v.err()
+}
+
+// path checks a repository path or path pattern.
+func (v *validator) path(field, value string) bool {
+	if !v.required(field, value) || !v.maxLen(field, value, MaxPathLength) {
+		return false
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			v.add(field, FieldInvalidFormat, "must not contain control characters")
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *GetPRRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.ID)
+
+	return v.err()
+}
+
+func (r *ListPRsRequest) validate(v *validator) {
+	if r.TeamName != "" {
+		v.name("team_name", r.TeamName)
+	}
+
+	if r.AuthorID != "" {
+		v.id("author_id", r.AuthorID)
+	}
+
+	if r.ReviewerID != "" {
+		v.id("reviewer_id", r.ReviewerID)
+	}
+
+	switch r.Status {
+	case "", "OPEN", "MERGED":
+	default:
+		v.add("status", FieldInvalidFormat, "must be OPEN or MERGED")
+	}
+
+	v.maxLen("q", r.Query, MaxNameLength)
+
+	if r.CreatedAfter != nil && r.CreatedBefore != nil && !r.CreatedAfter.Before(*r.CreatedBefore) {
+		v.add("created_before", FieldOutOfRange, "must be later than created_after")
+	}
+}
+
+func (r *MergePRRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.ID)
+
+	return v.err()
+}
+
+func (r *ChangeReviewerRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.PRID)
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *SetReviewStateRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.PRID)
+	v.id("user_id", r.UserID)
+
+	switch r.State {
+	case "ACCEPTED", "DECLINED", "APPROVED", "CHANGES_REQUESTED":
+	case "":
+		v.add("state", FieldRequired, "field is required")
+	default:
+		v.add("state", FieldInvalidFormat, "must be ACCEPTED, DECLINED, APPROVED or CHANGES_REQUESTED")
+	}
+
+	v.maxLen("reason", r.Reason, MaxNameLength)
+
+	return v.err()
+}
+
+func (r *SwapPRReviewerRequest) Validate() error {
+	var v validator
+
+	v.id("pull_request_id", r.PRID)
+	v.id("old_reviewer_id", r.OldUserID)
+
+	if r.NewReviewerID != "" {
+		v.id("new_reviewer_id", r.NewReviewerID)
+	}
+
+	for i, id := range r.Exclude {
+		v.id(fmt.Sprintf("exclude[%d]", i), id)
+	}
+
+	if r.NewReviewerID != "" && slices.Contains(r.Exclude, r.NewReviewerID) {
+		v.add("new_reviewer_id", FieldInvalidFormat, "new_reviewer_id is listed in exclude")
+	}
+
+	v.maxLen("reason", r.Reason, MaxNameLength)
+
+	return v.err()
+}
+
+func (r *ListUnavailabilityRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+
+	return v.err()
+}
+
+func (r *CreateUnavailabilityRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+	v.window(r.StartsAt, r.EndsAt, r.Reason)
+
+	return v.err()
+}
+
+func (r *UpdateUnavailabilityRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+	v.windowID(r.ID)
+	v.window(r.StartsAt, r.EndsAt, r.Reason)
+
+	return v.err()
+}
+
+func (r *DeleteUnavailabilityRequest) Validate() error {
+	var v validator
+
+	v.id("user_id", r.UserID)
+	v.windowID(r.ID)
+
+	return v.err()
+}
+
+func (v *validator) windowID(id int64) {
+	if id <= 0 {
+		v.add("id", FieldRequired, "must be a positive window id")
+	}
+}
+
+func (v *validator) window(startsAt, endsAt *time.Time, reason string) {
+	if startsAt == nil {
+		v.add("starts_at", FieldRequired, "field is required")
+	}
+
+	if endsAt == nil {
+		v.add("ends_at", FieldRequired, "field is required")
+	}
+
+	if startsAt != nil && endsAt != nil && !startsAt.Before(*endsAt) {
+		v.add("ends_at", FieldOutOfRange, "must be later than starts_at")
+	}
+
+	v.maxLen("reason", reason, MaxNameLength)
+}
+
+func (v *validator) tag(field, value string) {
+	if !v.required(field, value) || !v.maxLen(field, value, MaxTagLength) {
+		return
+	}
+
+	if !tagPattern.MatchString(value) {
+		v.add(field, FieldInvalidFormat,
+			"must start with a lowercase letter or digit and contain only lowercase letters, digits, '.', '_' or '-'")
+	}
+}
+
+// tags checks a list of tag names.
+func (v *validator) tags(field string, items []string) {
+	if len(items) > MaxTags {
+		v.add(field, FieldOutOfRange, fmt.Sprintf("must list at most %d tags", MaxTags))
+		return
+	}
+
+	seen := make(map[string]int, len(items))
+
+	for i, item := range items {
+		itemField := fmt.Sprintf("%s[%d]", field, i)
+
+		v.tag(itemField, item)
+
+		if first, ok := seen[item]; ok {
+			v.add(itemField, FieldDuplicate, fmt.Sprintf("duplicates %s[%d]", field, first))
+			continue
+		}
+
+		seen[item] = i
+	}
+}
+
+func (v *validator) tagMode(value string) {
+	if value != "" && value != "prefer" && value != "require" {
+		v.add("mode", FieldInvalidFormat, "must be one of prefer, require")
+	}
+}
+
+func (r *CreateTagRequest) Validate() error {
+	var v validator
+
+	v.tag("name", r.Name)
+	v.maxLen("description", r.Description, MaxNameLength)
+	v.tagMode(r.Mode)
+
+	return v.err()
+}
+
+func (r *UpdateTagRequest) Validate() error {
+	var v validator
+
+	v.tag("name", r.Name)
+	v.maxLen("description", r.Description, MaxNameLength)
+	v.tagMode(r.Mode)
+
+	return v.err()
+}
+
+func (r *GetTagRequest) Validate() error {
+	var v validator
+
+	v.tag("name", r.Name)
+
+	return v.err()
+}
+
+func (r *DeleteTagRequest) Validate() error {
+	var v validator
+
+	v.tag("name", r.Name)
+
+	return v.err()
+}
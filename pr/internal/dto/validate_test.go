@@ -0,0 +1,191 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apperrors "pr/internal/app/errors"
+)
+
+type validatable interface {
+	Validate() error
+}
+
+// fieldCodes maps each field of a validation error to its code.
+func fieldCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidationFailed {
+		t.Fatalf("error = %v, want a validation error", err)
+	}
+
+	codes := make(map[string]string, len(appErr.Details))
+	for _, d := range appErr.Details {
+		codes[d.Field] = d.Code
+	}
+
+	return codes
+}
+
+func TestValidate(t *testing.T) {
+	member := func(id, name string) User { return User{UserID: id, Username: name} }
+	negative := -1
+
+	tests := []struct {
+		name string
+		req  validatable
+		want map[string]string
+	}{
+		{
+			name: "team ok",
+			req: &CreateTeamRequest{TeamName: "backend", Members: []User{
+				member("u1", "Alice"), member("u.2@x-y_z", "Bob"),
+			}},
+		},
+		{
+			name: "team without name",
+			req:  &CreateTeamRequest{Members: []User{member("u1", "Alice")}},
+			want: map[string]string{"team_name": FieldRequired},
+		},
+		{
+			name: "member without username",
+			req:  &CreateTeamRequest{TeamName: "backend", Members: []User{member("u1", "")}},
+			want: map[string]string{"members[0].username": FieldRequired},
+		},
+		{
+			name: "member id formats",
+			req: &CreateTeamRequest{TeamName: "backend", Members: []User{
+				member("", "Alice"), member("-u2", "Bob"), member("u 3", "Carol"),
+				member(strings.Repeat("u", MaxIDLength+1), "Dave"),
+			}},
+			want: map[string]string{
+				"members[0].user_id": FieldRequired,
+				"members[1].user_id": FieldInvalidFormat,
+				"members[2].user_id": FieldInvalidFormat,
+				"members[3].user_id": FieldTooLong,
+			},
+		},
+		{
+			name: "duplicate members",
+			req:  &CreateTeamRequest{TeamName: "backend", Members: []User{member("u1", "Alice"), member("u1", "Bob")}},
+			want: map[string]string{"members[1].user_id": FieldDuplicate},
+		},
+		{
+			name: "control characters in names",
+			req:  &CreateTeamRequest{TeamName: "back\nend", Members: []User{member("u1", "Al\x00ice")}},
+			want: map[string]string{"team_name": FieldInvalidFormat, "members[0].username": FieldInvalidFormat},
+		},
+		{
+			name: "negative capacity and unknown role",
+			req: &CreateTeamRequest{TeamName: "backend", Members: []User{
+				{UserID: "u1", Username: "Alice", MaxOpenReviews: &negative, Role: "intern"},
+			}},
+			want: map[string]string{
+				"members[0].max_open_reviews": FieldOutOfRange,
+				"members[0].role":             FieldInvalidFormat,
+			},
+		},
+		{
+			name: "pr ok",
+			req:  &CreatePRRequest{ID: "pr-1", Name: "Add search", AuthorID: "u1", Labels: []string{"go", "db"}},
+		},
+		{
+			name: "pr without fields",
+			req:  &CreatePRRequest{},
+			want: map[string]string{
+				"pull_request_id":   FieldRequired,
+				"pull_request_name": FieldRequired,
+				"author_id":         FieldRequired,
+			},
+		},
+		{
+			name: "pr labels",
+			req:  &CreatePRRequest{ID: "pr-1", Name: "Add search", AuthorID: "u1", Labels: []string{"Go", "db", "db"}},
+			want: map[string]string{"labels[0]": FieldInvalidFormat, "labels[2]": FieldDuplicate},
+		},
+		{
+			name: "pr name at the limit",
+			req:  &CreatePRRequest{ID: "pr-1", Name: strings.Repeat("я", MaxNameLength), AuthorID: "u1"},
+		},
+		{
+			name: "pr name too long",
+			req:  &CreatePRRequest{ID: "pr-1", Name: strings.Repeat("я", MaxNameLength+1), AuthorID: "u1"},
+			want: map[string]string{"pull_request_name": FieldTooLong},
+		},
+		{
+			name: "review state",
+			req:  &SetReviewStateRequest{PRID: "pr-1", UserID: "u1", State: "LGTM"},
+			want: map[string]string{"state": FieldInvalidFormat},
+		},
+		{
+			name: "remove members ok",
+			req:  &RemoveTeamMembersRequest{TeamName: "backend", UserIDs: []string{"u1", "u2"}},
+		},
+		{
+			name: "remove no members",
+			req:  &RemoveTeamMembersRequest{TeamName: "backend"},
+			want: map[string]string{"user_ids": FieldRequired},
+		},
+		{
+			name: "remove bad and duplicate members",
+			req:  &RemoveTeamMembersRequest{UserIDs: []string{"u1", "", "u1", "u/4"}},
+			want: map[string]string{
+				"team_name":   FieldRequired,
+				"user_ids[1]": FieldRequired,
+				"user_ids[2]": FieldDuplicate,
+				"user_ids[3]": FieldInvalidFormat,
+			},
+		},
+		{
+			name: "rename ok",
+			req:  &RenameTeamRequest{TeamName: "backend", NewTeamName: "platform"},
+		},
+		{
+			name: "rename to the same name",
+			req:  &RenameTeamRequest{TeamName: "backend", NewTeamName: "backend"},
+			want: map[string]string{"new_team_name": FieldInvalidFormat},
+		},
+		{
+			name: "rename without a new name",
+			req:  &RenameTeamRequest{TeamName: "backend"},
+			want: map[string]string{"new_team_name": FieldRequired},
+		},
+		{
+			name: "delete without a name",
+			req:  &DeleteTeamRequest{},
+			want: map[string]string{"team_name": FieldRequired},
+		},
+		{
+			name: "move without policy",
+			req:  &MoveUserTeamRequest{UserID: "u1", TeamName: "backend"},
+			want: map[string]string{"policy": FieldRequired},
+		},
+		{
+			name: "swap excludes the target",
+			req:  &SwapPRReviewerRequest{PRID: "pr-1", OldUserID: "u1", NewReviewerID: "u2", Exclude: []string{"u2"}},
+			want: map[string]string{"new_reviewer_id": FieldInvalidFormat},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldCodes(t, tt.req.Validate())
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("details = %v, want %v", got, tt.want)
+			}
+
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Errorf("%s = %q, want %q (all: %v)", field, got[field], code, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/notify"
+	"pr/internal/repo/user"
+	"time"
+)
+
+// digestTimeout bounds building and sending one user's digest.
+const digestTimeout = 30 * time.Second
+
+// Digests sends the daily digest of open reviews to every user whose
+// digest time has come. Users with nothing to review get no message.
+func Digests(users user.UserInterface, n *notify.Notifier, log *slog.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		due, err := users.ClaimDueDigests(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to claim due digests", slog.Any("error", err))
+			return
+		}
+
+		for _, d := range due {
+			// an unsent digest stays claimed until the lease runs out and
+			// is then retried
+			if err := sendDigest(ctx, users, n, d); err != nil {
+				log.ErrorContext(ctx, "Failed to send digest",
+					slog.String("user_id", d.UserID), slog.Any("error", err))
+
+				continue
+			}
+
+			if err := users.MarkDigestSent(ctx, d); err != nil {
+				log.ErrorContext(ctx, "Failed to mark digest sent",
+					slog.String("user_id", d.UserID), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func sendDigest(ctx context.Context, users user.UserInterface, n *notify.Notifier, due user.DueDigest) error {
+	ctx, cancel := context.WithTimeout(ctx, digestTimeout)
+	defer cancel()
+
+	loc, err := time.LoadLocation(due.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	digest, err := notify.LoadDigest(ctx, users, due.UserID, time.Now().In(loc))
+	if err != nil || len(digest.Reviews) == 0 {
+		return err
+	}
+
+	return n.SendDigest(ctx, digest, due.Channel)
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"pr/internal/notify"
+	"pr/internal/repo/page"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/user"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type digestUsers struct {
+	user.UserInterface
+	due     []user.DueDigest
+	reviews map[string][]pr.PRWithDateModel
+	marked  []user.DueDigest
+}
+
+func (u *digestUsers) ClaimDueDigests(context.Context) ([]user.DueDigest, error) {
+	return u.due, nil
+}
+
+func (u *digestUsers) MarkDigestSent(_ context.Context, due user.DueDigest) error {
+	u.marked = append(u.marked, due)
+	return nil
+}
+
+func (u *digestUsers) GetPrUser(_ context.Context, userID string, _ user.ReviewFilter,
+	_ page.Request) ([]pr.PRWithDateModel, page.Info, error) {
+	return u.reviews[userID], page.Info{}, nil
+}
+
+func (u *digestUsers) GetNotificationSettings(context.Context, string) (user.NotificationSettings, error) {
+	return user.NotificationSettings{Default: true}, nil
+}
+
+type digestChannel struct {
+	failFor string
+	sent    map[string]notify.Message
+}
+
+func (c *digestChannel) Send(_ context.Context, to notify.Recipient, msg notify.Message) error {
+	if to.UserID == c.failFor {
+		return errors.New("channel down")
+	}
+
+	c.sent[to.UserID] = msg
+
+	return nil
+}
+
+func TestDigests(t *testing.T) {
+	created := time.Now().Add(-2 * time.Hour)
+	review := []pr.PRWithDateModel{{ID: "pr-1", Name: "Add search", AuthorID: "u9", CreatedAt: &created}}
+	on := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	users := &digestUsers{
+		due: []user.DueDigest{
+			{UserID: "tokyo", TimeZone: "Asia/Tokyo", On: on},
+			{UserID: "failing", TimeZone: "UTC", On: on},
+			{UserID: "idle", TimeZone: "UTC", On: on},
+			{UserID: "unknown-zone", TimeZone: "Mars/Olympus", On: on},
+		},
+		reviews: map[string][]pr.PRWithDateModel{"tokyo": review, "failing": review, "unknown-zone": review},
+	}
+
+	ch := &digestChannel{failFor: "failing", sent: make(map[string]notify.Message)}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	n := notify.New(users, map[string]notify.Channel{notify.ChannelLog: ch}, nil, notify.ChannelLog, log)
+
+	Digests(users, n, log)(context.Background())
+
+	// failed digests are not marked, so they stay claimed until the lease
+	// runs out and the next run retries them
+	var marked []string
+	for _, d := range users.marked {
+		marked = append(marked, d.UserID)
+
+		if !d.On.Equal(on) {
+			t.Errorf("%s marked for %v, want the claimed date %v", d.UserID, d.On, on)
+		}
+	}
+
+	if want := []string{"tokyo", "idle"}; !slices.Equal(marked, want) {
+		t.Errorf("marked = %v, want %v", marked, want)
+	}
+
+	if len(ch.sent) != 1 {
+		t.Fatalf("sent digests to %d users, want only tokyo", len(ch.sent))
+	}
+
+	// the digest is dated in the user's zone
+	if msg := ch.sent["tokyo"]; !strings.Contains(msg.Text, "JST") || !strings.Contains(msg.Text, "Add search") {
+		t.Errorf("tokyo digest = %q, want a JST date and the review", msg.Text)
+	}
+}
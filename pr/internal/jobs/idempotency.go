@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/idempotency"
+)
+
+// IdempotencyCleanup removes expired idempotency records.
+func IdempotencyCleanup(store idempotency.IdempotencyInterface, log *slog.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		deleted, err := store.DeleteExpired(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to delete expired idempotency keys", slog.Any("error", err))
+			return
+		}
+
+		if deleted > 0 {
+			log.InfoContext(ctx, "Deleted expired idempotency keys", slog.Int64("count", deleted))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/pr"
+)
+
+// ReviewSLA reminds reviewers of stale reviews and reassigns or escalates
+// the ones past the team's escalation threshold.
+func ReviewSLA(store pr.PRInterface, log *slog.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		events, err := store.ProcessStaleReviews(ctx)
+		for _, e := range events {
+			attrs := []any{
+				slog.String("pr_id", e.PRID),
+				slog.String("user_id", e.ReviewerID),
+				slog.Time("assigned_at", e.AssignedAt),
+			}
+
+			switch e.Action {
+			case pr.SLAReminder:
+				log.InfoContext(ctx, "Review reminder", attrs...)
+			case pr.SLAReassigned:
+				log.InfoContext(ctx, "Reassigned stale review", append(attrs, slog.String("replaced_by", e.ReplacedBy))...)
+			case pr.SLAEscalated:
+				log.WarnContext(ctx, "Escalated stale review", append(attrs, slog.String("lead_id", e.LeadID))...)
+			}
+		}
+
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to process stale reviews", slog.Any("error", err))
+		}
+	}
+}
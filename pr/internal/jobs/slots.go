@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/pr"
+)
+
+// FillReviewerSlots assigns reviewers to open PRs that were created, or
+// left, with fewer reviewers than required.
+func FillReviewerSlots(store pr.PRInterface, log *slog.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		fills, err := store.FillOpenSlots(ctx, "")
+		for _, f := range fills {
+			log.InfoContext(ctx, "Filled open review slots",
+				slog.String("pr_id", f.PRID),
+				slog.Any("reviewers", f.Reviewers),
+				slog.Int("unfilled_slots", f.UnfilledSlots),
+			)
+		}
+
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to fill open review slots", slog.Any("error", err))
+		}
+	}
+}
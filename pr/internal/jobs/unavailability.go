@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/unavailability"
+)
+
+// UnavailabilityHandover hands the open reviews of users whose
+// unavailability window has started over to their teammates.
+func UnavailabilityHandover(store unavailability.UnavailabilityInterface, log *slog.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		handovers, err := store.HandOverStarted(ctx)
+		for _, h := range handovers {
+			log.InfoContext(ctx, "Handed over reviews of unavailable user",
+				slog.String("user_id", h.UserID),
+				slog.Int64("window_id", h.WindowID),
+				slog.Int("reassigned", len(h.Reassigned)),
+				slog.Int("kept", h.Kept),
+			)
+		}
+
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to hand over reviews of unavailable users", slog.Any("error", err))
+		}
+	}
+}
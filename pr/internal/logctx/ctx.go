@@ -2,8 +2,6 @@ package logctx
 
 import (
 	"context"
-
-	"github.com/google/uuid"
 )
 
 type Ctx struct {
@@ -17,9 +15,7 @@ type keyType int
 
 const key keyType = 0
 
-func WithReqID(ctx context.Context) context.Context {
-	reqID := uuid.New().String()
-
+func WithReqID(ctx context.Context, reqID string) context.Context {
 	if c, ok := ctx.Value(key).(Ctx); ok {
 		c.ReqID = reqID
 		return context.WithValue(ctx, key, c)
@@ -54,3 +50,11 @@ func WithPR(ctx context.Context, prID string) context.Context {
 
 	return context.WithValue(ctx, key, Ctx{PR: prID})
 }
+
+func ReqID(ctx context.Context) string {
+	if c, ok := ctx.Value(key).(Ctx); ok {
+		return c.ReqID
+	}
+
+	return ""
+}
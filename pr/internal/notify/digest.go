@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"pr/internal/repo/page"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/user"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Digest lists the open reviews of a user. GeneratedAt is in the user's
+// time zone and is what review ages are measured against.
+type Digest struct {
+	GeneratedAt time.Time
+	UserID      string
+	Reviews     []DigestReview
+}
+
+type DigestReview struct {
+	CreatedAt time.Time
+	PRID      string
+	PRName    string
+	AuthorID  string
+}
+
+// ReviewSource lists the PRs a user reviews.
+type ReviewSource interface {
+	GetPrUser(ctx context.Context, userID string, filter user.ReviewFilter,
+		p page.Request) ([]pr.PRWithDateModel, page.Info, error)
+}
+
+// LoadDigest collects the open PRs userID reviews, oldest first.
+func LoadDigest(ctx context.Context, src ReviewSource, userID string, now time.Time) (Digest, error) {
+	d := Digest{GeneratedAt: now, UserID: userID}
+	p := page.Request{Limit: page.MaxLimit}
+
+	for {
+		prs, info, err := src.GetPrUser(ctx, userID, user.ReviewFilter{Status: "OPEN"}, p)
+		if err != nil {
+			return Digest{}, err
+		}
+
+		for _, m := range prs {
+			r := DigestReview{PRID: m.ID, PRName: m.Name, AuthorID: m.AuthorID}
+			if m.CreatedAt != nil {
+				r.CreatedAt = *m.CreatedAt
+			}
+
+			d.Reviews = append(d.Reviews, r)
+		}
+
+		if !info.HasMore {
+			return d, nil
+		}
+
+		p.Cursor = info.NextCursor
+	}
+}
+
+var (
+	digestSubject = template.Must(template.New("digest-subject").Parse(
+		`{{with .Reviews}}{{len .}} open review{{if gt (len .) 1}}s{{end}}{{else}}No open reviews{{end}} for {{.Date}}`))
+
+	digestText = template.Must(template.New("digest-text").Parse(
+		`{{if .Reviews}}Your open reviews as of {{.Date}}:
+{{range .Reviews}}
+- {{.Title}} by {{.AuthorID}}, open for {{.Age}}{{end}}
+{{else}}You have no open reviews.
+{{end}}`))
+
+	digestHTML = htmltemplate.Must(htmltemplate.New("digest-html").Parse(`<!DOCTYPE html>
+<html>
+<body>
+{{if .Reviews}}<p>Your open reviews as of {{.Date}}:</p>
+<table>
+<thead><tr><th>Pull request</th><th>Author</th><th>Open for</th></tr></thead>
+<tbody>
+{{range .Reviews}}<tr><td>{{.Title}}</td><td>{{.AuthorID}}</td><td>{{.Age}}</td></tr>
+{{end}}</tbody>
+</table>
+{{else}}<p>You have no open reviews.</p>
+{{end}}</body>
+</html>
+`))
+)
+
+// digestView is what the digest templates see.
+type digestView struct {
+	Date    string
+	Reviews []digestReviewView
+}
+
+type digestReviewView struct {
+	DigestReview
+	Age string
+}
+
+func (v digestReviewView) Title() string {
+	return view{Event{PRID: v.PRID, PRName: v.PRName}}.Title()
+}
+
+// RenderDigest builds the digest message with a plain text and an HTML body.
+func RenderDigest(d Digest) (Message, error) {
+	v := digestView{Date: d.GeneratedAt.Format("Mon, 02 Jan 2006 15:04 MST")}
+
+	for _, r := range d.Reviews {
+		v.Reviews = append(v.Reviews, digestReviewView{DigestReview: r, Age: Age(d.GeneratedAt.Sub(r.CreatedAt))})
+	}
+
+	var subject, text, html strings.Builder
+
+	if err := digestSubject.Execute(&subject, v); err != nil {
+		return Message{}, err
+	}
+
+	if err := digestText.Execute(&text, v); err != nil {
+		return Message{}, err
+	}
+
+	if err := digestHTML.Execute(&html, v); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Subject: subject.String(), Text: text.String(), HTML: html.String()}, nil
+}
+
+// Age formats how long a review has been open: minutes under an hour,
+// hours under a day, then days and hours.
+func Age(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", max(int(d/time.Minute), 0))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd %dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	}
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"pr/internal/repo/page"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/user"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{-time.Minute, "0m"},
+		{45 * time.Minute, "45m"},
+		{5*time.Hour + 59*time.Minute, "5h"},
+		{24 * time.Hour, "1d 0h"},
+		{3*24*time.Hour + 4*time.Hour + 30*time.Minute, "3d 4h"},
+	}
+
+	for _, tt := range tests {
+		if got := Age(tt.d); got != tt.want {
+			t.Errorf("Age(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2025, 10, 24, 9, 0, 0, 0, loc)
+
+	msg, err := RenderDigest(Digest{
+		GeneratedAt: now,
+		UserID:      "u2",
+		Reviews: []DigestReview{
+			{CreatedAt: now.Add(-50*time.Hour - 10*time.Minute), PRID: "pr-1", PRName: "Add <search>", AuthorID: "u1"},
+			{CreatedAt: now.Add(-3 * time.Hour), PRID: "pr-2", AuthorID: "u3"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RenderDigest: %v", err)
+	}
+
+	if want := "2 open reviews for Fri, 24 Oct 2025 09:00 CEST"; msg.Subject != want {
+		t.Errorf("subject = %q, want %q", msg.Subject, want)
+	}
+
+	wantText := "Your open reviews as of Fri, 24 Oct 2025 09:00 CEST:\n\n" +
+		"- Add <search> (pr-1) by u1, open for 2d 2h\n" +
+		"- pr-2 by u3, open for 3h\n"
+	if msg.Text != wantText {
+		t.Errorf("text = %q, want %q", msg.Text, wantText)
+	}
+
+	for _, want := range []string{
+		"<td>Add &lt;search&gt; (pr-1)</td><td>u1</td><td>2d 2h</td>",
+		"<td>pr-2</td><td>u3</td><td>3h</td>",
+	} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("html %q does not contain %q", msg.HTML, want)
+		}
+	}
+
+	empty, err := RenderDigest(Digest{GeneratedAt: now, UserID: "u2"})
+	if err != nil {
+		t.Fatalf("RenderDigest of no reviews: %v", err)
+	}
+
+	if !strings.HasPrefix(empty.Subject, "No open reviews") || empty.Text != "You have no open reviews.\n" {
+		t.Errorf("empty digest = %+v", empty)
+	}
+}
+
+// reviewPages serves GetPrUser one page at a time.
+type reviewPages [][]pr.PRWithDateModel
+
+func (p reviewPages) GetPrUser(_ context.Context, _ string, filter user.ReviewFilter,
+	req page.Request) ([]pr.PRWithDateModel, page.Info, error) {
+	if filter.Status != "OPEN" {
+		return nil, page.Info{}, nil
+	}
+
+	i := 0
+	if req.Cursor != "" {
+		i = int(req.Cursor[0] - '0')
+	}
+
+	info := page.Info{HasMore: i+1 < len(p)}
+	if info.HasMore {
+		info.NextCursor = string(rune('0' + i + 1))
+	}
+
+	return p[i], info, nil
+}
+
+func TestLoadDigest(t *testing.T) {
+	created := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+
+	d, err := LoadDigest(context.Background(), reviewPages{
+		{{ID: "pr-1", Name: "Add search", AuthorID: "u1", CreatedAt: &created}},
+		{{ID: "pr-2", AuthorID: "u3"}},
+	}, "u2", created.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("LoadDigest: %v", err)
+	}
+
+	if len(d.Reviews) != 2 || d.Reviews[0].PRID != "pr-1" || !d.Reviews[0].CreatedAt.Equal(created) ||
+		d.Reviews[1].PRID != "pr-2" {
+		t.Errorf("reviews = %+v, want pr-1 and pr-2 from both pages", d.Reviews)
+	}
+}
+
+func TestEmailSendHTML(t *testing.T) {
+	mailCh := make(chan string, 1)
+	addr := smtpStandIn(t, mailCh)
+
+	e := NewEmail(addr, "reviews@example.com", "", "")
+	msg := Message{Subject: "1 open review", Text: "- pr-1\n", HTML: "<p>pr-1</p>\n"}
+
+	if err := e.Send(context.Background(), Recipient{UserID: "u1", Email: "u1@example.com"}, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(<-mailCh))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", m.Header.Get("Content-Type"))
+	}
+
+	mr := multipart.NewReader(m.Body, params["boundary"])
+
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "- pr-1\r\n"},
+		{"text/html; charset=utf-8", "<p>pr-1</p>\r\n"},
+	} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != want.contentType || string(body) != want.body {
+			t.Errorf("part %q = %q, want %q %q", part.Header.Get("Content-Type"), body, want.contentType, want.body)
+		}
+	}
+}
+
+func TestNotifierSendDigest(t *testing.T) {
+	logCh, webhookCh := &recorder{}, &recorder{}
+
+	n := New(settingsStub{
+		"u2": {WebhookURL: "https://chat.example.com/hook"},
+	}, map[string]Channel{
+		ChannelLog:     logCh,
+		ChannelWebhook: webhookCh,
+	}, []string{ChannelLog}, ChannelLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := context.Background()
+	d := Digest{GeneratedAt: time.Now(), UserID: "u2"}
+
+	if err := n.SendDigest(ctx, d, ""); err != nil {
+		t.Fatalf("SendDigest through the default channel: %v", err)
+	}
+
+	if err := n.SendDigest(ctx, d, ChannelWebhook); err != nil {
+		t.Fatalf("SendDigest through the webhook: %v", err)
+	}
+
+	if len(logCh.sent) != 1 || len(webhookCh.sent) != 1 || webhookCh.sent[0].WebhookURL != "https://chat.example.com/hook" {
+		t.Errorf("log got %+v, webhook got %+v", logCh.sent, webhookCh.sent)
+	}
+
+	if err := n.SendDigest(ctx, d, ChannelEmail); err == nil {
+		t.Error("SendDigest through an unconfigured channel succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Email sends mail through an SMTP relay: plain text, or
+// multipart/alternative when the message has an HTML body.
+type Email struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewEmail returns an email channel for the relay at addr (host:port).
+// Without a username the relay is used unauthenticated.
+func NewEmail(addr, from, username, password string) *Email {
+	e := &Email{addr: addr, from: from}
+
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return e
+}
+
+func (e *Email) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.Email == "" {
+		return ErrNoAddress
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(crlf(msg.Text))
+		b.WriteString("\r\n")
+	} else if err := writeAlternative(&b, msg); err != nil {
+		return err
+	}
+
+	// net/smtp takes no context, so the send runs until it finishes on its own
+	done := make(chan error, 1)
+
+	go func() {
+		done <- smtp.SendMail(e.addr, e.auth, e.from, []string{to.Email}, []byte(b.String()))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeAlternative writes the Content-Type header and a body with the
+// text and HTML versions of msg, plainest first as RFC 2046 asks.
+func writeAlternative(b *strings.Builder, msg Message) error {
+	mw := multipart.NewWriter(b)
+
+	fmt.Fprintf(b, "Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	b.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(w, crlf(part.body)); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"pr/internal/repo/team"
+	"pr/internal/repo/unavailability"
+	"pr/internal/repo/user"
+)
+
+// userStore notifies the new reviewers of the reviews handed over when a
+// user moves to another team or is deleted.
+type userStore struct {
+	user.UserInterface
+	n *Notifier
+}
+
+// WrapUser returns store with notifications sent after each successful
+// team move and deletion.
+func WrapUser(store user.UserInterface, n *Notifier) user.UserInterface {
+	return &userStore{UserInterface: store, n: n}
+}
+
+func (s *userStore) MoveTeam(ctx context.Context, userID, teamName,
+	policy string) (user.UserModel, string, []user.ReassignedReview, error) {
+	res, fromTeam, reassigned, err := s.UserInterface.MoveTeam(ctx, userID, teamName, policy)
+	if err == nil {
+		s.n.handedOver(ctx, reassigned)
+	}
+
+	return res, fromTeam, reassigned, err
+}
+
+func (s *userStore) DeleteUser(ctx context.Context, userID string) (user.UserModel, []user.ReassignedReview, error) {
+	res, reassigned, err := s.UserInterface.DeleteUser(ctx, userID)
+	if err == nil {
+		s.n.handedOver(ctx, reassigned)
+	}
+
+	return res, reassigned, err
+}
+
+// teamStore notifies the new reviewers of the reviews handed over when
+// members are removed from a team.
+type teamStore struct {
+	team.UserInterface
+	n *Notifier
+}
+
+// WrapTeam returns store with notifications sent after each successful
+// member removal.
+func WrapTeam(store team.UserInterface, n *Notifier) team.UserInterface {
+	return &teamStore{UserInterface: store, n: n}
+}
+
+func (s *teamStore) RemoveMembers(ctx context.Context, teamName string, userIDs []string,
+	expectedVersion *int64) (team.TeamModel, []user.ReassignedReview, error) {
+	res, reassigned, err := s.UserInterface.RemoveMembers(ctx, teamName, userIDs, expectedVersion)
+	if err == nil {
+		s.n.handedOver(ctx, reassigned)
+	}
+
+	return res, reassigned, err
+}
+
+// unavailabilityStore notifies the new reviewers of the reviews handed
+// over when unavailability windows start.
+type unavailabilityStore struct {
+	unavailability.UnavailabilityInterface
+	n *Notifier
+}
+
+// WrapUnavailability returns store with notifications sent for every
+// review the handover job reassigns.
+func WrapUnavailability(store unavailability.UnavailabilityInterface,
+	n *Notifier) unavailability.UnavailabilityInterface {
+	return &unavailabilityStore{UnavailabilityInterface: store, n: n}
+}
+
+func (s *unavailabilityStore) HandOverStarted(ctx context.Context) ([]unavailability.HandoverModel, error) {
+	handovers, err := s.UnavailabilityInterface.HandOverStarted(ctx)
+	for _, h := range handovers {
+		s.n.handedOver(ctx, h.Reassigned)
+	}
+
+	return handovers, err
+}
+
+// handedOver tells each new reviewer about the review they took over.
+// Reviews nobody took send nothing.
+func (n *Notifier) handedOver(ctx context.Context, reassigned []user.ReassignedReview) {
+	events := make([]Event, 0, len(reassigned))
+	for _, r := range reassigned {
+		if r.ReplacedBy == "" {
+			continue
+		}
+
+		events = append(events, Event{
+			Kind:      KindReassigned,
+			UserID:    r.ReplacedBy,
+			PRID:      r.PRID,
+			PRName:    r.PRName,
+			AuthorID:  r.AuthorID,
+			OldUserID: r.ReviewerID,
+		})
+	}
+
+	n.Notify(ctx, events...)
+}
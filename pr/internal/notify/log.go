@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log writes notifications to the service log; meant for development.
+type Log struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (l *Log) Send(ctx context.Context, to Recipient, msg Message) error {
+	l.log.InfoContext(ctx, "Notification",
+		slog.String("user_id", to.UserID),
+		slog.String("subject", msg.Subject),
+		slog.String("text", msg.Text),
+	)
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package notify tells users about their reviews through pluggable
+// channels: email, a chat webhook or the service log.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pr/internal/repo/user"
+	"slices"
+	"time"
+)
+
+// event kinds, also used in the users' event filters
+const (
+	KindAssigned   = "assigned"
+	KindReassigned = "reassigned"
+	KindUnassigned = "unassigned"
+	KindMerged     = "merged"
+	KindReminder   = "reminder"
+	KindEscalated  = "escalated"
+)
+
+// channel names
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+)
+
+// queueSize bounds the events waiting for delivery.
+const queueSize = 256
+
+// sendTimeout bounds the delivery of one event over all channels.
+const sendTimeout = 10 * time.Second
+
+// ErrNoAddress is returned by a channel that has nowhere to send to.
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+// Event is something a user should hear about. UserID is the recipient.
+type Event struct {
+	AssignedAt time.Time
+	Kind       string
+	UserID     string
+	PRID       string
+	PRName     string
+	AuthorID   string
+	// OldUserID is the previous reviewer (KindReassigned) or the reviewer
+	// who let the review go stale (KindEscalated).
+	OldUserID string
+}
+
+// Recipient is where a user receives notifications.
+type Recipient struct {
+	UserID     string
+	Email      string
+	WebhookURL string
+}
+
+// Message is a rendered notification. HTML is an optional alternative
+// body for channels that can show it.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Channel interface {
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
+
+// SettingsSource looks up the users' notification settings.
+type SettingsSource interface {
+	GetNotificationSettings(ctx context.Context, userID string) (user.NotificationSettings, error)
+}
+
+type Notifier struct {
+	settings SettingsSource
+	channels map[string]Channel
+	defaults []string
+	queue    chan Event
+	log      *slog.Logger
+
+	digestChannel string
+}
+
+// New returns a notifier sending through channels, keyed by channel name.
+// Users who never saved settings get the defaults channels; digests go
+// through digestChannel unless the user picked another one.
+func New(settings SettingsSource, channels map[string]Channel, defaults []string, digestChannel string,
+	log *slog.Logger) *Notifier {
+	return &Notifier{
+		settings:      settings,
+		channels:      channels,
+		defaults:      defaults,
+		queue:         make(chan Event, queueSize),
+		log:           log,
+		digestChannel: digestChannel,
+	}
+}
+
+// Notify queues events for Run. It never blocks; when the queue is full
+// the event is dropped. It reports whether every event was queued.
+func (n *Notifier) Notify(ctx context.Context, events ...Event) bool {
+	queued := true
+
+	for _, e := range events {
+		select {
+		case n.queue <- e:
+		default:
+			n.log.WarnContext(ctx, "Notification queue is full, dropping event",
+				slog.String("kind", e.Kind), slog.String("user_id", e.UserID), slog.String("pr_id", e.PRID))
+
+			queued = false
+		}
+	}
+
+	return queued
+}
+
+// Run delivers queued events until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-n.queue:
+			sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+
+			if err := n.Deliver(sendCtx, e); err != nil {
+				n.log.ErrorContext(ctx, "Failed to deliver notification",
+					slog.String("kind", e.Kind), slog.String("user_id", e.UserID),
+					slog.String("pr_id", e.PRID), slog.Any("error", err))
+			}
+
+			cancel()
+		}
+	}
+}
+
+// SendDigest sends the rendered digest through channel, or through the
+// digest default channel when channel is empty.
+func (n *Notifier) SendDigest(ctx context.Context, d Digest, channel string) error {
+	if channel == "" {
+		channel = n.digestChannel
+	}
+
+	ch, ok := n.channels[channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not configured", channel)
+	}
+
+	settings, err := n.settings.GetNotificationSettings(ctx, d.UserID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := RenderDigest(d)
+	if err != nil {
+		return err
+	}
+
+	return ch.Send(ctx, Recipient{UserID: d.UserID, Email: settings.Email, WebhookURL: settings.WebhookURL}, msg)
+}
+
+// Deliver renders e and sends it through every channel the recipient has
+// enabled, skipping event kinds the recipient filtered out.
+func (n *Notifier) Deliver(ctx context.Context, e Event) error {
+	settings, err := n.settings.GetNotificationSettings(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	channels := settings.Channels
+	if settings.Default {
+		channels = n.defaults
+	}
+
+	if len(settings.Events) > 0 && !slices.Contains(settings.Events, e.Kind) {
+		return nil
+	}
+
+	msg, err := Render(e)
+	if err != nil {
+		return err
+	}
+
+	to := Recipient{UserID: e.UserID, Email: settings.Email, WebhookURL: settings.WebhookURL}
+
+	var errs []error
+
+	for _, name := range channels {
+		ch, ok := n.channels[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("channel %s is not configured", name))
+			continue
+		}
+
+		if err := ch.Send(ctx, to, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
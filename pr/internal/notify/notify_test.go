@@ -0,0 +1,391 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/user"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	assignedAt := time.Date(2025, 10, 24, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		event   Event
+		subject string
+		text    string
+	}{
+		{
+			event:   Event{Kind: KindAssigned, PRID: "pr-1", PRName: "Add search", AuthorID: "u1"},
+			subject: "Review requested: Add search (pr-1)",
+			text:    "You were assigned to review Add search (pr-1) by u1.",
+		},
+		{
+			event:   Event{Kind: KindReassigned, PRID: "pr-1", OldUserID: "u2"},
+			subject: "Review handed over to you: pr-1",
+			text:    "You now review pr-1, taking over from u2.",
+		},
+		{
+			event:   Event{Kind: KindUnassigned, PRID: "pr-1", PRName: "Add search"},
+			subject: "Review no longer needed: Add search (pr-1)",
+			text:    "You were removed from the reviewers of Add search (pr-1).",
+		},
+		{
+			event:   Event{Kind: KindMerged, PRID: "pr-1", PRName: "Add search"},
+			subject: "Merged: Add search (pr-1)",
+			text:    "Add search (pr-1) was merged.",
+		},
+		{
+			event:   Event{Kind: KindReminder, PRID: "pr-1", AssignedAt: assignedAt},
+			subject: "Reminder: pr-1 is waiting for your review",
+			text:    "pr-1 has been waiting for your review since 2025-10-24 12:00 UTC.",
+		},
+		{
+			event:   Event{Kind: KindEscalated, PRID: "pr-1", OldUserID: "u2", AssignedAt: assignedAt},
+			subject: "Stale review: pr-1",
+			text:    "u2 has not reviewed pr-1 since 2025-10-24 12:00 UTC.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.event.Kind, func(t *testing.T) {
+			msg, err := Render(tt.event)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+
+			if msg.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", msg.Subject, tt.subject)
+			}
+
+			if msg.Text != tt.text {
+				t.Errorf("text = %q, want %q", msg.Text, tt.text)
+			}
+		})
+	}
+
+	if _, err := Render(Event{Kind: "unknown"}); err == nil {
+		t.Error("Render of an unknown kind succeeded")
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	var got webhookPayload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook("", srv.Client())
+	msg := Message{Subject: "Merged: pr-1", Text: "pr-1 was merged."}
+
+	if err := wh.Send(context.Background(), Recipient{UserID: "u1", WebhookURL: srv.URL}, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if want := "*Merged: pr-1*\npr-1 was merged."; got.Text != want {
+		t.Errorf("text = %q, want %q", got.Text, want)
+	}
+
+	if err := wh.Send(context.Background(), Recipient{UserID: "u1"}, msg); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("Send without a URL: err = %v, want ErrNoAddress", err)
+	}
+}
+
+func TestWebhookSendFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	// the channel-wide URL is used when the recipient has none
+	wh := NewWebhook(srv.URL, srv.Client())
+
+	if err := wh.Send(context.Background(), Recipient{UserID: "u1"}, Message{}); err == nil {
+		t.Error("Send succeeded on a 500 response")
+	}
+}
+
+// smtpStandIn accepts one message per connection and hands its DATA to mail.
+func smtpStandIn(t *testing.T, mail chan<- string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveSMTP(conn, mail)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func serveSMTP(conn net.Conn, mail chan<- string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+
+			var data strings.Builder
+
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if l == ".\r\n" {
+					break
+				}
+
+				data.WriteString(l)
+			}
+
+			mail <- data.String()
+
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	mail := make(chan string, 1)
+	addr := smtpStandIn(t, mail)
+
+	e := NewEmail(addr, "reviews@example.com", "", "")
+	msg := Message{Subject: "Review requested: pr-1", Text: "You were assigned to review pr-1."}
+
+	if err := e.Send(context.Background(), Recipient{UserID: "u1", Email: "u1@example.com"}, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := <-mail
+
+	for _, want := range []string{
+		"From: reviews@example.com\r\n",
+		"To: u1@example.com\r\n",
+		"Subject: Review requested: pr-1\r\n",
+		"\r\n\r\nYou were assigned to review pr-1.\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message %q does not contain %q", got, want)
+		}
+	}
+
+	if err := e.Send(context.Background(), Recipient{UserID: "u1"}, msg); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("Send without an address: err = %v, want ErrNoAddress", err)
+	}
+}
+
+type settingsStub map[string]user.NotificationSettings
+
+func (s settingsStub) GetNotificationSettings(_ context.Context, userID string) (user.NotificationSettings, error) {
+	settings, ok := s[userID]
+	if !ok {
+		return user.NotificationSettings{Default: true}, nil
+	}
+
+	return settings, nil
+}
+
+type recorder struct {
+	sent []Recipient
+}
+
+func (r *recorder) Send(_ context.Context, to Recipient, _ Message) error {
+	r.sent = append(r.sent, to)
+	return nil
+}
+
+func TestNotifierDeliver(t *testing.T) {
+	logCh, webhookCh := &recorder{}, &recorder{}
+
+	n := New(settingsStub{
+		"u2": {Channels: []string{ChannelWebhook}, WebhookURL: "https://chat.example.com/hook"},
+		"u3": {Channels: []string{ChannelWebhook}, Events: []string{KindMerged}},
+		"u4": {Channels: []string{ChannelEmail}},
+	}, map[string]Channel{
+		ChannelLog:     logCh,
+		ChannelWebhook: webhookCh,
+	}, []string{ChannelLog}, ChannelLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := context.Background()
+	event := func(userID string) Event {
+		return Event{Kind: KindAssigned, UserID: userID, PRID: "pr-1"}
+	}
+
+	// no saved settings: default channels
+	if err := n.Deliver(ctx, event("u1")); err != nil {
+		t.Fatalf("Deliver to u1: %v", err)
+	}
+
+	if err := n.Deliver(ctx, event("u2")); err != nil {
+		t.Fatalf("Deliver to u2: %v", err)
+	}
+
+	// u3 only wants merges
+	if err := n.Deliver(ctx, event("u3")); err != nil {
+		t.Fatalf("Deliver to u3: %v", err)
+	}
+
+	if len(logCh.sent) != 1 || logCh.sent[0].UserID != "u1" {
+		t.Errorf("log channel got %+v, want only u1", logCh.sent)
+	}
+
+	if len(webhookCh.sent) != 1 || webhookCh.sent[0].WebhookURL != "https://chat.example.com/hook" {
+		t.Errorf("webhook channel got %+v, want only u2 with their URL", webhookCh.sent)
+	}
+
+	if err := n.Deliver(ctx, event("u4")); err == nil {
+		t.Error("Deliver through an unconfigured channel succeeded")
+	}
+}
+
+func TestHandedOver(t *testing.T) {
+	n := New(settingsStub{}, nil, nil, ChannelLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	n.handedOver(context.Background(), []user.ReassignedReview{
+		{PRID: "pr-1", PRName: "Add search", AuthorID: "u1", ReviewerID: "u2", ReplacedBy: "u3"},
+		// nobody took pr-2, so nobody hears about it
+		{PRID: "pr-2", AuthorID: "u1", ReviewerID: "u2"},
+	})
+
+	if got := len(n.queue); got != 1 {
+		t.Fatalf("queued %d events, want 1", got)
+	}
+
+	e := <-n.queue
+	if e.Kind != KindReassigned || e.UserID != "u3" || e.OldUserID != "u2" || e.PRID != "pr-1" {
+		t.Errorf("queued %+v, want pr-1 handed over from u2 to u3", e)
+	}
+}
+
+// mergeStub merges its PR on the first call only.
+type mergeStub struct {
+	pr.PRInterface
+	merged bool
+}
+
+func (s *mergeStub) MergePR(_ context.Context, id string, _ bool,
+	_ *int64) (*pr.PRWithDateModel, pr.MergeResult, error) {
+	res := pr.MergeResult{Reviewers: []string{"u2", "u3"}, Merged: !s.merged}
+	s.merged = true
+
+	return &pr.PRWithDateModel{ID: id, Status: "MERGED"}, res, nil
+}
+
+func TestWrapPRMergeNotifiesOnce(t *testing.T) {
+	n := New(settingsStub{}, nil, nil, ChannelLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	store := WrapPR(&mergeStub{}, n)
+
+	for range 2 {
+		if _, _, err := store.MergePR(context.Background(), "pr-1", false, nil); err != nil {
+			t.Fatalf("MergePR: %v", err)
+		}
+	}
+
+	if got := len(n.queue); got != 2 {
+		t.Fatalf("queued %d events, want one per reviewer of the first merge", got)
+	}
+
+	for range 2 {
+		if e := <-n.queue; e.Kind != KindMerged || e.PRID != "pr-1" {
+			t.Errorf("queued %+v, want a merge of pr-1", e)
+		}
+	}
+}
+
+// staleStub reports a reminder for every call and records cleared marks.
+type staleStub struct {
+	pr.PRInterface
+	cleared []string
+}
+
+func (s *staleStub) ProcessStaleReviews(context.Context) ([]pr.SLAEvent, error) {
+	return []pr.SLAEvent{{Action: pr.SLAReminder, PRID: "pr-1", ReviewerID: "u2"}}, nil
+}
+
+func (s *staleStub) ClearReminder(_ context.Context, prID, reviewerID string) error {
+	s.cleared = append(s.cleared, prID+"/"+reviewerID)
+	return nil
+}
+
+func (s *staleStub) GetPR(_ context.Context, id string) (*pr.PRWithDateModel, []string, error) {
+	return &pr.PRWithDateModel{ID: id, Name: "Add search", AuthorID: "u1"}, nil, nil
+}
+
+func TestWrapPRClearsDroppedReminder(t *testing.T) {
+	n := New(settingsStub{}, nil, nil, ChannelLog, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	stub := &staleStub{}
+	store := WrapPR(stub, n)
+
+	if _, err := store.ProcessStaleReviews(context.Background()); err != nil {
+		t.Fatalf("ProcessStaleReviews: %v", err)
+	}
+
+	if len(stub.cleared) != 0 || len(n.queue) != 1 {
+		t.Fatalf("queued %d, cleared %v; want the reminder queued and kept", len(n.queue), stub.cleared)
+	}
+
+	for len(n.queue) < cap(n.queue) {
+		n.Notify(context.Background(), Event{Kind: KindAssigned})
+	}
+
+	if _, err := store.ProcessStaleReviews(context.Background()); err != nil {
+		t.Fatalf("ProcessStaleReviews: %v", err)
+	}
+
+	if want := []string{"pr-1/u2"}; !slices.Equal(stub.cleared, want) {
+		t.Errorf("cleared %v, want the dropped reminder %v", stub.cleared, want)
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/pr"
+)
+
+// prStore notifies reviewers of the assignment changes made through the
+// wrapped PR repository, by requests and by background jobs alike.
+type prStore struct {
+	pr.PRInterface
+	n *Notifier
+}
+
+// WrapPR returns store with notifications sent after each successful
+// assignment change, merge and SLA action.
+func WrapPR(store pr.PRInterface, n *Notifier) pr.PRInterface {
+	return &prStore{PRInterface: store, n: n}
+}
+
+func (s *prStore) CreatePR(ctx context.Context, prm pr.PRModel) (pr.PRModel, pr.AssignResult, error) {
+	created, res, err := s.PRInterface.CreatePR(ctx, prm)
+	if err == nil {
+		s.assigned(ctx, created, res.Reviewers)
+	}
+
+	return created, res, err
+}
+
+func (s *prStore) AddPRReviewer(ctx context.Context, prID, userID string,
+	expectedVersion *int64) (pr.PRModel, []string, error) {
+	prm, reviewers, err := s.PRInterface.AddPRReviewer(ctx, prID, userID, expectedVersion)
+	if err == nil {
+		s.assigned(ctx, prm, []string{userID})
+	}
+
+	return prm, reviewers, err
+}
+
+func (s *prStore) SwapPRReviewer(ctx context.Context, req pr.SwapRequest,
+	expectedVersion *int64) (pr.PRModel, pr.AssignResult, error) {
+	prm, res, err := s.PRInterface.SwapPRReviewer(ctx, req, expectedVersion)
+	if err == nil {
+		s.reassigned(ctx, prm, res.ReplacedBy, req.OldReviewerID)
+	}
+
+	return prm, res, err
+}
+
+func (s *prStore) RemovePRReviewer(ctx context.Context, prID, userID string,
+	expectedVersion *int64) (pr.PRModel, []string, error) {
+	prm, reviewers, err := s.PRInterface.RemovePRReviewer(ctx, prID, userID, expectedVersion)
+	if err == nil {
+		s.n.Notify(ctx, Event{
+			Kind:     KindUnassigned,
+			UserID:   userID,
+			PRID:     prm.ID,
+			PRName:   prm.Name,
+			AuthorID: prm.AuthorID,
+		})
+	}
+
+	return prm, reviewers, err
+}
+
+func (s *prStore) SetReviewState(ctx context.Context, req pr.ReviewStateRequest,
+	expectedVersion *int64) (pr.PRModel, pr.AssignResult, error) {
+	prm, res, err := s.PRInterface.SetReviewState(ctx, req, expectedVersion)
+	if err == nil && res.ReplacedBy != "" {
+		s.reassigned(ctx, prm, res.ReplacedBy, req.UserID)
+	}
+
+	return prm, res, err
+}
+
+func (s *prStore) MergePR(ctx context.Context, id string, override bool,
+	expectedVersion *int64) (*pr.PRWithDateModel, pr.MergeResult, error) {
+	merged, res, err := s.PRInterface.MergePR(ctx, id, override, expectedVersion)
+	// merging a merged PR is a no-op and must not notify again
+	if err == nil && res.Merged {
+		events := make([]Event, 0, len(res.Reviewers))
+		for _, r := range res.Reviewers {
+			events = append(events, Event{
+				Kind:     KindMerged,
+				UserID:   r,
+				PRID:     merged.ID,
+				PRName:   merged.Name,
+				AuthorID: merged.AuthorID,
+			})
+		}
+
+		s.n.Notify(ctx, events...)
+	}
+
+	return merged, res, err
+}
+
+func (s *prStore) FillOpenSlots(ctx context.Context, teamName string) ([]pr.SlotFill, error) {
+	fills, err := s.PRInterface.FillOpenSlots(ctx, teamName)
+	for _, f := range fills {
+		s.assigned(ctx, s.lookup(ctx, f.PRID), f.Reviewers)
+	}
+
+	return fills, err
+}
+
+func (s *prStore) ProcessStaleReviews(ctx context.Context) ([]pr.SLAEvent, error) {
+	events, err := s.PRInterface.ProcessStaleReviews(ctx)
+	for _, e := range events {
+		prm := s.lookup(ctx, e.PRID)
+
+		switch e.Action {
+		case pr.SLAReminder:
+			queued := s.n.Notify(ctx, Event{
+				Kind:       KindReminder,
+				UserID:     e.ReviewerID,
+				PRID:       prm.ID,
+				PRName:     prm.Name,
+				AuthorID:   prm.AuthorID,
+				AssignedAt: e.AssignedAt,
+			})
+
+			// a dropped reminder is sent again by the next run
+			if !queued {
+				if err := s.PRInterface.ClearReminder(ctx, e.PRID, e.ReviewerID); err != nil {
+					s.n.log.ErrorContext(ctx, "Failed to clear dropped reminder",
+						slog.String("pr_id", e.PRID), slog.String("user_id", e.ReviewerID), slog.Any("error", err))
+				}
+			}
+		case pr.SLAReassigned:
+			s.reassigned(ctx, prm, e.ReplacedBy, e.ReviewerID)
+		case pr.SLAEscalated:
+			if e.LeadID != "" {
+				s.n.Notify(ctx, Event{
+					Kind:       KindEscalated,
+					UserID:     e.LeadID,
+					PRID:       prm.ID,
+					PRName:     prm.Name,
+					AuthorID:   prm.AuthorID,
+					OldUserID:  e.ReviewerID,
+					AssignedAt: e.AssignedAt,
+				})
+			}
+		}
+	}
+
+	return events, err
+}
+
+func (s *prStore) assigned(ctx context.Context, prm pr.PRModel, reviewers []string) {
+	events := make([]Event, 0, len(reviewers))
+	for _, r := range reviewers {
+		events = append(events, Event{
+			Kind:     KindAssigned,
+			UserID:   r,
+			PRID:     prm.ID,
+			PRName:   prm.Name,
+			AuthorID: prm.AuthorID,
+		})
+	}
+
+	s.n.Notify(ctx, events...)
+}
+
+func (s *prStore) reassigned(ctx context.Context, prm pr.PRModel, newReviewer, oldReviewer string) {
+	s.n.Notify(ctx, Event{
+		Kind:      KindReassigned,
+		UserID:    newReviewer,
+		PRID:      prm.ID,
+		PRName:    prm.Name,
+		AuthorID:  prm.AuthorID,
+		OldUserID: oldReviewer,
+	})
+}
+
+// lookup returns the PR for a notification; if it can't be read, the
+// message goes out with the id alone.
+func (s *prStore) lookup(ctx context.Context, id string) pr.PRModel {
+	p, _, err := s.PRInterface.GetPR(ctx, id)
+	if err != nil {
+		return pr.PRModel{ID: id}
+	}
+
+	return pr.PRModel{ID: p.ID, Name: p.Name, AuthorID: p.AuthorID, Status: p.Status, Version: p.Version}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+type messageTemplate struct {
+	subject *template.Template
+	text    *template.Template
+}
+
+func newTemplate(kind, subject, text string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New(kind + "-subject").Parse(subject)),
+		text:    template.Must(template.New(kind + "-text").Parse(text)),
+	}
+}
+
+// templates render each event kind; {{.Title}} is the PR name with its id.
+var templates = map[string]messageTemplate{
+	KindAssigned: newTemplate(KindAssigned,
+		`Review requested: {{.Title}}`,
+		`You were assigned to review {{.Title}}{{with .AuthorID}} by {{.}}{{end}}.`),
+	KindReassigned: newTemplate(KindReassigned,
+		`Review handed over to you: {{.Title}}`,
+		`You now review {{.Title}}{{with .OldUserID}}, taking over from {{.}}{{end}}.`),
+	KindUnassigned: newTemplate(KindUnassigned,
+		`Review no longer needed: {{.Title}}`,
+		`You were removed from the reviewers of {{.Title}}.`),
+	KindMerged: newTemplate(KindMerged,
+		`Merged: {{.Title}}`,
+		`{{.Title}} was merged.`),
+	KindReminder: newTemplate(KindReminder,
+		`Reminder: {{.Title}} is waiting for your review`,
+		`{{.Title}} has been waiting for your review since {{.Since}}.`),
+	KindEscalated: newTemplate(KindEscalated,
+		`Stale review: {{.Title}}`,
+		`{{with .OldUserID}}{{.}} has{{else}}The reviewer has{{end}} not reviewed {{.Title}} since {{.Since}}.`),
+}
+
+// view is what the templates see.
+type view struct {
+	Event
+}
+
+func (v view) Title() string {
+	if v.PRName == "" {
+		return v.PRID
+	}
+
+	return fmt.Sprintf("%s (%s)", v.PRName, v.PRID)
+}
+
+func (v view) Since() string {
+	return v.AssignedAt.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// Render builds the message for e.
+func Render(e Event) (Message, error) {
+	t, ok := templates[e.Kind]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for event kind %q", e.Kind)
+	}
+
+	var subject, text strings.Builder
+
+	if err := t.subject.Execute(&subject, view{e}); err != nil {
+		return Message{}, err
+	}
+
+	if err := t.text.Execute(&text, view{e}); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Subject: subject.String(), Text: text.String()}, nil
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Webhook posts to a Slack-compatible incoming webhook. The recipient's
+// own webhook URL wins over the channel-wide one.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Webhook{url: url, client: client}
+}
+
+type webhookPayload struct {
+	Text string `json:"text"`
+}
+
+func (wh *Webhook) Send(ctx context.Context, to Recipient, msg Message) error {
+	url := to.WebhookURL
+	if url == "" {
+		url = wh.url
+	}
+
+	if url == "" {
+		return ErrNoAddress
+	}
+
+	body, err := json.Marshal(webhookPayload{Text: "*" + msg.Subject + "*\n" + msg.Text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,981 @@
+// Package assign picks and changes the reviewers of a PR inside the
+// caller's transaction. It is shared by the repos that move reviewers
+// around: PRs, users, teams and unavailability windows.
+package assign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/codeowners"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/tag"
+	"pr/internal/repo/user"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// availableNow keeps users (aliased u) who are not inside an
+// unavailability window.
+const availableNow = `NOT EXISTS (
+			SELECT 1 FROM user_unavailability AS ua
+			WHERE ua.user_id = u.id AND ua.starts_at <= NOW() AND ua.ends_at > NOW()
+		  )`
+
+// hasCapacity is true for users (aliased u) below their open review limit.
+const hasCapacity = `(u.max_open_reviews IS NULL OR (
+			SELECT COUNT(*)
+			FROM users_pull_requests AS cap
+				JOIN pull_requests AS cpr
+					ON cpr.id = cap.pull_requests_id
+			WHERE cap.users_id = u.id AND cpr.status = 'OPEN'
+		  ) < u.max_open_reviews)`
+
+// candidateTeams lists the team $1 (rank 0) followed by its fallback
+// teams in their configured order.
+const candidateTeams = `(
+			SELECT $1::text AS team_name, 0 AS rank
+			UNION ALL
+			SELECT fallback_team, position FROM team_fallbacks WHERE team_name = $1
+		  )`
+
+// Replacement is the reviewer picked by ReplaceReviewer.
+type Replacement struct {
+	ReviewerID       string
+	Version          int64
+	CapacityExceeded bool
+	FromFallback     bool
+}
+
+// ReplaceOptions tune ReplaceReviewer.
+type ReplaceOptions struct {
+	// NewReviewerID is the replacement picked by hand; it is checked like
+	// any random candidate would be.
+	NewReviewerID string
+	// Exclude are users never picked as the random replacement.
+	Exclude []string
+	// Reason is recorded in the PR history.
+	Reason string
+}
+
+// ReplaceReviewer swaps oldReviewerID on the PR for a random active
+// member of the author's team (or, failing that, of its fallback teams)
+// who is not assigned to it yet (or for opts.NewReviewerID, if set),
+// records the change and bumps the PR version. When the old reviewer is
+// needed for the team's role rule, the replacement must have a rule role
+// too or it fails with ROLE_RULE_UNSATISFIED. The caller must hold the
+// PR row lock in tx.
+func ReplaceReviewer(ctx context.Context, tx pgx.Tx, prm pr.PRModel, oldReviewerID string,
+	opts ReplaceOptions, policy pr.AssignmentPolicy) (Replacement, error) {
+	teamName, err := AuthorTeam(ctx, tx, prm.AuthorID)
+	if err != nil {
+		return Replacement{}, err
+	}
+
+	roles, err := replacementRoles(ctx, tx, prm.ID, teamName, oldReviewerID)
+	if err != nil {
+		return Replacement{}, err
+	}
+
+	var candidates []Candidate
+
+	if opts.NewReviewerID != "" {
+		c, err := CheckTarget(ctx, tx, prm, teamName, opts.NewReviewerID)
+		if err != nil {
+			return Replacement{}, err
+		}
+
+		if roles != nil {
+			var hasRole bool
+			if err := tx.QueryRow(ctx, "SELECT COALESCE(role = ANY($2), false) FROM users WHERE id = $1",
+				c.id, roles).Scan(&hasRole); err != nil {
+				return Replacement{}, err
+			}
+
+			if !hasRole {
+				return Replacement{}, apperrors.ErrRoleRuleUnsatisfied
+			}
+		}
+
+		candidates = []Candidate{c}
+	} else {
+		candidates, err = queryCandidates(ctx, tx, `
+			SELECT u.id, `+hasCapacity+` AS has_capacity, ct.rank > 0 AS from_fallback
+			FROM users u
+				JOIN `+candidateTeams+` AS ct
+					ON ct.team_name = u.team_name
+			WHERE u.is_active = true
+			  AND u.deleted_at IS NULL
+			  AND `+availableNow+`
+			  AND u.id != $2
+			  AND u.id != $3
+			  AND u.id != ALL($5)
+			  AND u.id NOT IN (
+			      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $4
+			  )
+			  AND u.id NOT IN (
+			      SELECT user_id FROM pull_request_declines WHERE pull_request_id = $4
+			  )
+			  AND ($6::text[] IS NULL OR u.role = ANY($6))
+			ORDER BY has_capacity DESC, ct.rank, RANDOM()
+			LIMIT 1`,
+			teamName, oldReviewerID, prm.AuthorID, prm.ID, append([]string{}, opts.Exclude...), roles)
+		if err != nil {
+			return Replacement{}, err
+		}
+	}
+
+	if len(candidates) == 0 {
+		if roles != nil {
+			return Replacement{}, apperrors.ErrRoleRuleUnsatisfied
+		}
+
+		return Replacement{}, apperrors.ErrNoCandidate
+	}
+
+	picked, exceeded, err := pickWithinCapacity(candidates, policy)
+	if err != nil {
+		return Replacement{}, err
+	}
+
+	res := Replacement{ReviewerID: picked[0].id, CapacityExceeded: exceeded, FromFallback: picked[0].fromFallback}
+
+	reason := opts.Reason
+	if reason == "" && res.FromFallback {
+		reason = pr.ReasonFallbackTeam
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users_pull_requests
+		SET users_id = $1, state = 'PENDING', state_updated_at = NOW(),
+			assigned_at = NOW(), reminded_at = NULL, escalated_at = NULL
+		WHERE pull_requests_id = $2 AND users_id = $3`,
+		res.ReviewerID, prm.ID, oldReviewerID)
+	if err != nil {
+		return Replacement{}, err
+	}
+
+	if err := AddHistory(ctx, tx, prm.ID, pr.EventReviewerReassigned, res.ReviewerID, oldReviewerID, reason); err != nil {
+		return Replacement{}, err
+	}
+
+	if err = tx.QueryRow(ctx, `
+		UPDATE pull_requests SET version = version + 1 WHERE id = $1 RETURNING version`,
+		prm.ID).Scan(&res.Version); err != nil {
+		return Replacement{}, err
+	}
+
+	return res, nil
+}
+
+// replacementRoles returns the roles the replacement of oldReviewerID must
+// have: the team's rule roles if the old reviewer has one and the other
+// reviewers don't meet the rule without them, nil otherwise.
+func replacementRoles(ctx context.Context, q querier, prID, teamName, oldReviewerID string) ([]string, error) {
+	rule, err := TeamRoleRule(ctx, q, teamName)
+	if err != nil || rule.Min == 0 {
+		return nil, err
+	}
+
+	var (
+		oldHasRole bool
+		others     int
+	)
+
+	if err := q.QueryRow(ctx, `
+		SELECT COALESCE(bool_or(u.id = $2), false), COUNT(*) FILTER (WHERE u.id != $2)
+		FROM users_pull_requests AS upr
+			JOIN users AS u
+				ON u.id = upr.users_id
+		WHERE upr.pull_requests_id = $1 AND u.role = ANY($3)`,
+		prID, oldReviewerID, rule.Roles).Scan(&oldHasRole, &others); err != nil {
+		return nil, err
+	}
+
+	if !oldHasRole || others >= rule.Min {
+		return nil, nil
+	}
+
+	return rule.Roles, nil
+}
+
+// AuthorTeam returns the team of the PR author, "" for a teamless one.
+func AuthorTeam(ctx context.Context, q querier, authorID string) (string, error) {
+	var teamName string
+
+	if err := q.QueryRow(ctx, "SELECT COALESCE(team_name, '') FROM users WHERE id = $1",
+		authorID).Scan(&teamName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apperrors.ErrNotFound
+		}
+
+		return "", err
+	}
+
+	return teamName, nil
+}
+
+// CheckTarget applies the candidate rules to a reviewer picked by hand:
+// not the author, not assigned yet and never declined, an active and
+// available member of the author's team or of its fallback teams.
+func CheckTarget(ctx context.Context, q querier, prm pr.PRModel, authorTeam, userID string) (Candidate, error) {
+	if userID == prm.AuthorID {
+		return Candidate{}, apperrors.ErrReviewerIsAuthor
+	}
+
+	var (
+		c                         = Candidate{id: userID}
+		active, available, inTeam bool
+		assigned, declined        bool
+	)
+
+	err := q.QueryRow(ctx, `
+		SELECT u.is_active AND u.deleted_at IS NULL,
+			`+availableNow+`,
+			`+hasCapacity+`,
+			EXISTS (SELECT 1 FROM `+candidateTeams+` AS ct WHERE ct.team_name = u.team_name),
+			COALESCE((SELECT ct.rank > 0 FROM `+candidateTeams+` AS ct WHERE ct.team_name = u.team_name), false),
+			EXISTS (SELECT 1 FROM users_pull_requests WHERE pull_requests_id = $3 AND users_id = u.id),
+			EXISTS (SELECT 1 FROM pull_request_declines WHERE pull_request_id = $3 AND user_id = u.id)
+		FROM users AS u
+		WHERE u.id = $2`,
+		authorTeam, userID, prm.ID).Scan(&active, &available, &c.hasCapacity, &inTeam, &c.fromFallback,
+		&assigned, &declined)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Candidate{}, apperrors.ErrNotFound
+		}
+
+		return Candidate{}, err
+	}
+
+	switch {
+	case assigned:
+		return Candidate{}, apperrors.ErrAlreadyAssigned
+	case declined:
+		return Candidate{}, apperrors.ErrReviewerDeclined
+	case !inTeam:
+		return Candidate{}, apperrors.ErrReviewerNotInTeam
+	case !active:
+		return Candidate{}, apperrors.ErrUserInactive
+	case !available:
+		return Candidate{}, apperrors.ErrUserUnavailable
+	}
+
+	return c, nil
+}
+
+// CheckAddition applies the rest of the hand-picked replacement rules to
+// a reviewer that passed CheckTarget and is added next to the assigned
+// ones: the capacity limit under policy, and the team's role rule, which
+// must still be reachable with the slots left once the reviewer takes one.
+func CheckAddition(ctx context.Context, q querier, prID, authorTeam string, c Candidate, assigned int,
+	policy pr.AssignmentPolicy) error {
+	if _, _, err := pickWithinCapacity([]Candidate{c}, policy); err != nil {
+		return err
+	}
+
+	rule, err := TeamRoleRule(ctx, q, authorTeam)
+	if err != nil || rule.Min == 0 {
+		return err
+	}
+
+	var hasRole bool
+
+	if err := q.QueryRow(ctx, "SELECT COALESCE(role = ANY($2), false) FROM users WHERE id = $1",
+		c.id, rule.Roles).Scan(&hasRole); err != nil {
+		return err
+	}
+
+	if hasRole {
+		return nil
+	}
+
+	have, err := CountWithRole(ctx, q, prID, rule.Roles)
+	if err != nil {
+		return err
+	}
+
+	if rule.Min-have > pr.RequiredReviewers-assigned-1 {
+		return apperrors.ErrRoleRuleUnsatisfied
+	}
+
+	return nil
+}
+
+// CheckRemoval fails with ROLE_RULE_UNSATISFIED when the PR's other
+// reviewers don't meet the team's role rule without reviewerID.
+func CheckRemoval(ctx context.Context, q querier, prID, authorTeam, reviewerID string) error {
+	roles, err := replacementRoles(ctx, q, prID, authorTeam, reviewerID)
+	if err != nil {
+		return err
+	}
+
+	if roles != nil {
+		return apperrors.ErrRoleRuleUnsatisfied
+	}
+
+	return nil
+}
+
+// PickOwners picks up to n code owners of the PR's changed files, best
+// ranked first, among the users PickNewReviewers would consider. Owners
+// at capacity are left out; the random pick may still take them.
+func PickOwners(ctx context.Context, q querier, authorTeam string, prm pr.PRModel, n int,
+	exclude []string) ([]Candidate, error) {
+	if len(prm.ChangedFiles) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	rules, err := codeOwnerRules(ctx, q, authorTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := codeowners.Owners(rules, prm.ChangedFiles)
+	if len(owners) == 0 {
+		return nil, nil
+	}
+
+	candidates, err := queryCandidates(ctx, q, `
+		SELECT u.id, `+hasCapacity+` AS has_capacity, ct.rank > 0 AS from_fallback
+		FROM users AS u
+			JOIN `+candidateTeams+` AS ct
+				ON ct.team_name = u.team_name
+		WHERE u.id = ANY($4)
+		  AND u.id != $2
+		  AND u.is_active = true
+		  AND u.deleted_at IS NULL
+		  AND `+availableNow+`
+		  AND u.id NOT IN (
+		      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $3
+		  )
+		  AND u.id NOT IN (
+		      SELECT user_id FROM pull_request_declines WHERE pull_request_id = $3
+		  )
+		  AND u.id != ALL($5)`,
+		authorTeam, prm.AuthorID, prm.ID, owners, append([]string{}, exclude...))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Candidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.id] = c
+	}
+
+	var picked []Candidate
+
+	for _, id := range owners {
+		c, ok := byID[id]
+		if !ok || !c.hasCapacity {
+			continue
+		}
+
+		c.codeOwner = true
+		picked = append(picked, c)
+
+		if len(picked) == n {
+			break
+		}
+	}
+
+	return picked, nil
+}
+
+// codeOwnerRules loads the code owner rules of the team in match order.
+func codeOwnerRules(ctx context.Context, q querier, teamName string) ([]codeowners.Rule, error) {
+	rows, err := q.Query(ctx, `
+		SELECT pattern, owners FROM team_code_owners WHERE team_name = $1 ORDER BY position`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []codeowners.Rule
+
+	for rows.Next() {
+		var rule codeowners.Rule
+		if err := rows.Scan(&rule.Pattern, &rule.Owners); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// RequiredLabels checks that the labels are tags of the catalog and
+// returns those in require mode.
+func RequiredLabels(ctx context.Context, q querier, labels []string) ([]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	rows, err := q.Query(ctx, "SELECT name, match_mode FROM tags WHERE name = ANY($1)", labels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modes := make(map[string]string, len(labels))
+
+	for rows.Next() {
+		var name, mode string
+		if err := rows.Scan(&name, &mode); err != nil {
+			return nil, err
+		}
+
+		modes[name] = mode
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		required []string
+		details  []apperrors.FieldError
+	)
+
+	for i, label := range labels {
+		mode, ok := modes[label]
+
+		switch {
+		case !ok:
+			details = append(details, apperrors.FieldError{
+				Field:   fmt.Sprintf("labels[%d]", i),
+				Code:    "NOT_FOUND",
+				Message: "tag does not exist",
+			})
+		case mode == tag.ModeRequire:
+			required = append(required, label)
+		}
+	}
+
+	if len(details) > 0 {
+		return nil, apperrors.NewValidationError(details)
+	}
+
+	return required, nil
+}
+
+// PickSkilled picks at most n reviewers, among the users PickNewReviewers
+// would consider, so that every required label is a skill of one of them.
+// Each round takes the candidate covering the most labels still
+// uncovered, preferring candidates with capacity, then the author's team,
+// then those with one of roles. It fails with REQUIRED_SKILL_UNAVAILABLE
+// naming the labels that are left uncovered.
+func PickSkilled(ctx context.Context, q querier, authorTeam string, prm pr.PRModel, required, roles []string,
+	n int, policy pr.AssignmentPolicy) ([]Candidate, bool, error) {
+	if len(required) == 0 {
+		return nil, false, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT u.id, `+hasCapacity+` AS has_capacity, ct.rank > 0 AS from_fallback,
+			COALESCE(u.role = ANY($5), false) AS has_role,
+			ARRAY(SELECT us.tag FROM user_skills AS us WHERE us.user_id = u.id AND us.tag = ANY($4))
+		FROM users AS u
+			JOIN `+candidateTeams+` AS ct
+				ON ct.team_name = u.team_name
+		WHERE u.id != $2
+		  AND u.is_active = true
+		  AND u.deleted_at IS NULL
+		  AND `+availableNow+`
+		  AND EXISTS (SELECT 1 FROM user_skills AS us WHERE us.user_id = u.id AND us.tag = ANY($4))
+		  AND u.id NOT IN (
+		      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $3
+		  )
+		  AND u.id NOT IN (
+		      SELECT user_id FROM pull_request_declines WHERE pull_request_id = $3
+		  )
+		ORDER BY has_capacity DESC, ct.rank, has_role DESC, RANDOM()`,
+		authorTeam, prm.AuthorID, prm.ID, required, append([]string{}, roles...))
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	type skilledCandidate struct {
+		Candidate
+		skills []string
+	}
+
+	var candidates []skilledCandidate
+
+	for rows.Next() {
+		var c skilledCandidate
+		if err := rows.Scan(&c.id, &c.hasCapacity, &c.fromFallback, &c.hasRole, &c.skills); err != nil {
+			return nil, false, err
+		}
+
+		if c.hasCapacity || policy.ExceedCapacity {
+			c.skilled = true
+			candidates = append(candidates, c)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	uncovered := make(map[string]bool, len(required))
+	for _, label := range required {
+		uncovered[label] = true
+	}
+
+	var (
+		picked   []Candidate
+		exceeded bool
+	)
+
+	for len(uncovered) > 0 && len(picked) < n {
+		best, bestCovers := -1, 0
+
+		// candidates are already in preference order, so the first one
+		// with the best cover wins ties
+		for i, c := range candidates {
+			covers := 0
+			for _, s := range c.skills {
+				if uncovered[s] {
+					covers++
+				}
+			}
+
+			if covers > bestCovers {
+				best, bestCovers = i, covers
+			}
+		}
+
+		if best < 0 {
+			break
+		}
+
+		c := candidates[best]
+		for _, s := range c.skills {
+			delete(uncovered, s)
+		}
+
+		exceeded = exceeded || !c.hasCapacity
+		picked = append(picked, c.Candidate)
+		candidates = slices.Delete(candidates, best, best+1)
+	}
+
+	if len(uncovered) > 0 {
+		msg := "no available reviewer has this skill"
+		if len(picked) == n {
+			msg = fmt.Sprintf("the %d reviewers cannot cover all required labels", n)
+		}
+
+		var details []apperrors.FieldError
+
+		for i, label := range prm.Labels {
+			if uncovered[label] {
+				details = append(details, apperrors.FieldError{
+					Field:   fmt.Sprintf("labels[%d]", i),
+					Code:    "NO_REVIEWER",
+					Message: msg,
+				})
+			}
+		}
+
+		return nil, false, apperrors.NewRequiredSkillError(details)
+	}
+
+	return picked, exceeded, nil
+}
+
+// RoleRule is the role rule of a team: at least Min reviewers must have
+// one of Roles.
+type RoleRule struct {
+	Roles []string
+	Min   int
+}
+
+// TeamRoleRule loads the role rule of the team; a missing team has none.
+func TeamRoleRule(ctx context.Context, q querier, teamName string) (RoleRule, error) {
+	var (
+		rule    RoleRule
+		minRole string
+	)
+
+	err := q.QueryRow(ctx, "SELECT min_role_reviewers, min_reviewer_role FROM teams WHERE name = $1",
+		teamName).Scan(&rule.Min, &minRole)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return RoleRule{}, nil
+		}
+
+		return RoleRule{}, err
+	}
+
+	if rule.Min > 0 {
+		rule.Roles = user.RolesFrom(minRole)
+	}
+
+	return rule, nil
+}
+
+// CountWithRole counts the reviewers of the PR who have one of roles.
+func CountWithRole(ctx context.Context, q querier, prID string, roles []string) (int, error) {
+	var n int
+
+	err := q.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM users_pull_requests AS upr
+			JOIN users AS u
+				ON u.id = upr.users_id
+		WHERE upr.pull_requests_id = $1 AND u.role = ANY($2)`,
+		prID, roles).Scan(&n)
+
+	return n, err
+}
+
+// PickByRole picks, among the users PickNewReviewers would consider and
+// not in picked, as many reviewers with a rule role as picked lacks. It
+// fails with ROLE_RULE_UNSATISFIED when they don't fit in n slots or
+// can't be found.
+func PickByRole(ctx context.Context, q querier, authorTeam string, prm pr.PRModel, rule RoleRule,
+	picked []Candidate, n int, policy pr.AssignmentPolicy) ([]Candidate, bool, error) {
+	need := rule.Min
+	for _, c := range picked {
+		if c.hasRole {
+			need--
+		}
+	}
+
+	if need <= 0 {
+		return nil, false, nil
+	}
+
+	if need > n {
+		return nil, false, apperrors.ErrRoleRuleUnsatisfied
+	}
+
+	candidates, err := queryCandidates(ctx, q, `
+		SELECT u.id, `+hasCapacity+` AS has_capacity, ct.rank > 0 AS from_fallback
+		FROM users AS u
+			JOIN `+candidateTeams+` AS ct
+				ON ct.team_name = u.team_name
+		WHERE u.id != $2
+		  AND u.is_active = true
+		  AND u.deleted_at IS NULL
+		  AND `+availableNow+`
+		  AND u.role = ANY($5)
+		  AND u.id NOT IN (
+		      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $3
+		  )
+		  AND u.id NOT IN (
+		      SELECT user_id FROM pull_request_declines WHERE pull_request_id = $3
+		  )
+		  AND u.id != ALL($6)
+		ORDER BY has_capacity DESC, ct.rank, RANDOM()
+		LIMIT $4`,
+		authorTeam, prm.AuthorID, prm.ID, need, rule.Roles, CandidateIDs(picked))
+	if err != nil {
+		return nil, false, err
+	}
+
+	ranked, exceeded, err := pickWithinCapacity(candidates, policy)
+	if err != nil && !errors.Is(err, apperrors.ErrNoCapacity) {
+		return nil, false, err
+	}
+
+	if len(ranked) < need {
+		return nil, false, apperrors.ErrRoleRuleUnsatisfied
+	}
+
+	for i := range ranked {
+		ranked[i].hasRole = true
+		ranked[i].forRole = true
+	}
+
+	return ranked, exceeded, nil
+}
+
+// PickNewReviewers picks up to n active members of the author's team, or
+// of its fallback teams for the slots the team can't fill, who are not
+// the author, not in exclude and not assigned to the PR yet. Within a
+// team, members with more skills matching the PR's labels come first.
+func PickNewReviewers(ctx context.Context, q querier, authorTeam string, prm pr.PRModel, n int,
+	exclude []string, policy pr.AssignmentPolicy) ([]Candidate, bool, error) {
+	if n <= 0 {
+		return nil, false, nil
+	}
+
+	candidates, err := queryCandidates(ctx, q, `
+		SELECT u.id, `+hasCapacity+` AS has_capacity, ct.rank > 0 AS from_fallback
+		FROM users AS u
+			JOIN `+candidateTeams+` AS ct
+				ON ct.team_name = u.team_name
+		WHERE u.id != $2
+		  AND u.is_active = true
+		  AND u.deleted_at IS NULL
+		  AND `+availableNow+`
+		  AND u.id NOT IN (
+		      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $3
+		  )
+		  AND u.id NOT IN (
+		      SELECT user_id FROM pull_request_declines WHERE pull_request_id = $3
+		  )
+		  AND u.id != ALL($5)
+		ORDER BY has_capacity DESC, ct.rank,
+			(SELECT COUNT(*) FROM user_skills AS us WHERE us.user_id = u.id AND us.tag = ANY($6)) DESC,
+			RANDOM()
+		LIMIT $4`,
+		authorTeam, prm.AuthorID, prm.ID, n, append([]string{}, exclude...), append([]string{}, prm.Labels...))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return pickWithinCapacity(candidates, policy)
+}
+
+// AssignReviewers adds the picked reviewers to the PR and records them in
+// its history. It returns all assigned ids and those from fallback teams.
+func AssignReviewers(ctx context.Context, tx pgx.Tx, prID string, picked []Candidate) ([]string, []string, error) {
+	var ids, fallback []string
+
+	for _, c := range picked {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO users_pull_requests (pull_requests_id, users_id)
+			VALUES ($1, $2)`,
+			prID, c.id); err != nil {
+			return nil, nil, err
+		}
+
+		var reason string
+		if c.fromFallback {
+			reason = pr.ReasonFallbackTeam
+			fallback = append(fallback, c.id)
+		}
+
+		if c.codeOwner {
+			reason = pr.ReasonCodeOwner
+		}
+
+		if c.skilled {
+			reason = pr.ReasonRequiredSkill
+		}
+
+		if c.forRole {
+			reason = pr.ReasonRoleRule
+		}
+
+		if err := AddHistory(ctx, tx, prID, pr.EventReviewerAssigned, c.id, "", reason); err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, c.id)
+	}
+
+	return ids, fallback, nil
+}
+
+// Candidate is a user picked as a reviewer, with why they were picked.
+type Candidate struct {
+	id           string
+	hasCapacity  bool
+	fromFallback bool
+	codeOwner    bool
+	// skilled candidates cover some of the PR's required labels
+	skilled bool
+	// hasRole is set when the candidate counts for the team's role rule
+	// and forRole when they were picked for it
+	hasRole bool
+	forRole bool
+}
+
+// CandidateIDs returns the user ids of the candidates in order.
+func CandidateIDs(candidates []Candidate) []string {
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.id)
+	}
+
+	return ids
+}
+
+// queryCandidates runs a query selecting (id, has_capacity, from_fallback) rows.
+func queryCandidates(ctx context.Context, q querier, sql string, args ...any) ([]Candidate, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.id, &c.hasCapacity, &c.fromFallback); err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// pickWithinCapacity drops candidates at capacity unless the policy lets
+// them be exceeded. It fails with NO_CAPACITY when there were candidates
+// but all of them are at capacity.
+func pickWithinCapacity(candidates []Candidate, policy pr.AssignmentPolicy) ([]Candidate, bool, error) {
+	var (
+		picked   []Candidate
+		exceeded bool
+	)
+
+	for _, c := range candidates {
+		if !c.hasCapacity {
+			if !policy.ExceedCapacity {
+				continue
+			}
+
+			exceeded = true
+		}
+
+		picked = append(picked, c)
+	}
+
+	if len(picked) == 0 && len(candidates) > 0 {
+		return nil, false, apperrors.ErrNoCapacity
+	}
+
+	return picked, exceeded, nil
+}
+
+// Unfillable reports whether err only means that no reviewer could be
+// picked: no candidate, none with capacity or none meeting the role rule.
+func Unfillable(err error) bool {
+	return errors.Is(err, apperrors.ErrNoCandidate) || errors.Is(err, apperrors.ErrNoCapacity) ||
+		errors.Is(err, apperrors.ErrRoleRuleUnsatisfied)
+}
+
+// MoveHandOver picks the open reviews a reviewer moving to another team
+// hands over under policy: none with keep, all with reassign. fail refuses
+// the move while any are open.
+func MoveHandOver(policy string, open []pr.PRModel) ([]pr.PRModel, error) {
+	switch policy {
+	case user.MovePolicyKeep:
+		return nil, nil
+	case user.MovePolicyFail:
+		if len(open) > 0 {
+			return nil, apperrors.ErrHasOpenReviews
+		}
+
+		return nil, nil
+	default:
+		return open, nil
+	}
+}
+
+// HandOverReviews replaces userID on every given PR. A PR without a
+// candidate just loses the reviewer and its slot is left for the filler;
+// it is reported without ReplacedBy.
+func HandOverReviews(ctx context.Context, tx pgx.Tx, open []pr.PRModel, userID, reason string,
+	policy pr.AssignmentPolicy) ([]user.ReassignedReview, error) {
+	reassigned := make([]user.ReassignedReview, 0, len(open))
+
+	for _, prm := range open {
+		replacement, err := ReplaceReviewer(ctx, tx, prm, userID, ReplaceOptions{Reason: reason}, policy)
+		if Unfillable(err) {
+			_, err = RemoveReviewer(ctx, tx, prm.ID, userID, reason, true)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		reassigned = append(reassigned, user.ReassignedReview{
+			PRID:       prm.ID,
+			PRName:     prm.Name,
+			AuthorID:   prm.AuthorID,
+			ReviewerID: userID,
+			ReplacedBy: replacement.ReviewerID,
+		})
+	}
+
+	return reassigned, nil
+}
+
+// RemoveReviewer unassigns the reviewer from the PR without a
+// replacement. With queueSlot the freed slot is left for the filler. The
+// caller must hold the PR row lock in tx.
+func RemoveReviewer(ctx context.Context, tx pgx.Tx, prID, reviewerID, reason string, queueSlot bool) (int64, error) {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM users_pull_requests
+		WHERE pull_requests_id = $1 AND users_id = $2`,
+		prID, reviewerID); err != nil {
+		return 0, err
+	}
+
+	if err := AddHistory(ctx, tx, prID, pr.EventReviewerRemoved, "", reviewerID, reason); err != nil {
+		return 0, err
+	}
+
+	var version int64
+
+	if err := tx.QueryRow(ctx, `
+		UPDATE pull_requests
+		SET version = version + 1, unfilled_slots = unfilled_slots + CASE WHEN $2 THEN 1 ELSE 0 END
+		WHERE id = $1
+		RETURNING version`,
+		prID, queueSlot).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// LockOpenReviews locks and returns the open PRs the user reviews.
+func LockOpenReviews(ctx context.Context, tx pgx.Tx, userID string) ([]pr.PRModel, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.version
+		FROM users_pull_requests AS upr
+			JOIN pull_requests AS pr
+				ON upr.pull_requests_id = pr.id
+		WHERE upr.users_id = $1 AND pr.status = 'OPEN'
+		ORDER BY pr.id
+		FOR UPDATE OF pr`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var open []pr.PRModel
+
+	for rows.Next() {
+		var body pr.PRModel
+		if err := rows.Scan(&body.ID, &body.Name, &body.AuthorID, &body.Status, &body.Version); err != nil {
+			return nil, err
+		}
+
+		open = append(open, body)
+	}
+
+	return open, rows.Err()
+}
+
+// AddHistory appends an event to the PR history. Empty ids and reason are
+// stored as NULL.
+func AddHistory(ctx context.Context, q querier, prID, event, userID, oldUserID, reason string) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO pull_request_history (pull_request_id, event, user_id, old_user_id, reason)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''))`,
+		prID, event, userID, oldUserID, reason)
+
+	return err
+}
@@ -0,0 +1,39 @@
+package assign
+
+import (
+	"errors"
+	"testing"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/user"
+)
+
+func TestMoveHandOver(t *testing.T) {
+	open := []pr.PRModel{{ID: "pr-1"}, {ID: "pr-2"}}
+
+	tests := []struct {
+		policy string
+		open   []pr.PRModel
+		want   int
+		err    error
+	}{
+		{policy: user.MovePolicyKeep, open: open, want: 0},
+		{policy: user.MovePolicyReassign, open: open, want: 2},
+		{policy: user.MovePolicyReassign, open: nil, want: 0},
+		{policy: user.MovePolicyFail, open: open, err: apperrors.ErrHasOpenReviews},
+		{policy: user.MovePolicyFail, open: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		handOver, err := MoveHandOver(tt.policy, tt.open)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s with %d open: err = %v, want %v", tt.policy, len(tt.open), err, tt.err)
+			continue
+		}
+
+		if len(handOver) != tt.want {
+			t.Errorf("%s with %d open: hands over %d, want %d", tt.policy, len(tt.open), len(handOver), tt.want)
+		}
+	}
+}
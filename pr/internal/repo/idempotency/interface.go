@@ -0,0 +1,17 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type IdempotencyInterface interface {
+	// Reserve claims key for a request with the given hash. If the key is
+	// held by an unexpired record, that record is returned and reserved is false.
+	// In-progress reservations older than lockTimeout are considered abandoned.
+	Reserve(ctx context.Context, key, requestHash string, ttl, lockTimeout time.Duration) (RecordModel, bool, error)
+	Complete(ctx context.Context, key string, status int, headers http.Header, body []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
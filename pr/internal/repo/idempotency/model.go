@@ -0,0 +1,12 @@
+package idempotency
+
+import "net/http"
+
+type RecordModel struct {
+	Headers     http.Header
+	Key         string
+	RequestHash string
+	Body        []byte
+	StatusCode  int
+	Completed   bool
+}
@@ -0,0 +1,100 @@
+package idempotencypostgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"pr/internal/repo/idempotency"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyPostgres struct {
+	conn *pgxpool.Pool
+	log  *slog.Logger
+}
+
+func (ip *IdempotencyPostgres) Reserve(ctx context.Context, key, requestHash string,
+	ttl, lockTimeout time.Duration) (idempotency.RecordModel, bool, error) {
+	var reservedKey string
+
+	err := ip.conn.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			headers = NULL,
+			body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		   OR (idempotency_keys.status_code IS NULL
+		       AND idempotency_keys.created_at <= NOW() - make_interval(secs => $4))
+		RETURNING key`,
+		key, requestHash, ttl.Seconds(), lockTimeout.Seconds()).Scan(&reservedKey)
+
+	if err == nil {
+		return idempotency.RecordModel{Key: key, RequestHash: requestHash}, true, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return idempotency.RecordModel{}, false, err
+	}
+
+	var (
+		rec    idempotency.RecordModel
+		status *int
+	)
+
+	err = ip.conn.QueryRow(ctx, `
+		SELECT key, request_hash, status_code, headers, body
+		FROM idempotency_keys
+		WHERE key = $1`, key).Scan(&rec.Key, &rec.RequestHash, &status, &rec.Headers, &rec.Body)
+	if err != nil {
+		return idempotency.RecordModel{}, false, err
+	}
+
+	if status != nil {
+		rec.StatusCode = *status
+		rec.Completed = true
+	}
+
+	return rec, false, nil
+}
+
+func (ip *IdempotencyPostgres) Complete(ctx context.Context, key string, status int,
+	headers http.Header, body []byte) error {
+	_, err := ip.conn.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $2, headers = $3, body = $4
+		WHERE key = $1`,
+		key, status, headers, body)
+
+	return err
+}
+
+func (ip *IdempotencyPostgres) Release(ctx context.Context, key string) error {
+	_, err := ip.conn.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key)
+
+	return err
+}
+
+func (ip *IdempotencyPostgres) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := ip.conn.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func NewIdempotencyPostgres(conn *pgxpool.Pool, log *slog.Logger) idempotency.IdempotencyInterface {
+	return &IdempotencyPostgres{
+		conn: conn,
+		log:  log,
+	}
+}
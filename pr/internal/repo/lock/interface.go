@@ -0,0 +1,10 @@
+package lock
+
+import "context"
+
+type LockInterface interface {
+	// WithLock runs fn while holding the named lock, shared by every
+	// instance of the service. If another instance holds it, fn is not
+	// called and locked is false.
+	WithLock(ctx context.Context, name string, fn func(ctx context.Context)) (locked bool, err error)
+}
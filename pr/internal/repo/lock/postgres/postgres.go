@@ -0,0 +1,118 @@
+package lockpostgres
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LockPostgres uses session-level advisory locks, keyed by the hash of the
+// lock name. The locks live on a connection of their own, outside the
+// pool: a locked job draws its queries from the pool, so lock sessions
+// taken from it could hold every pooled connection while the jobs wait
+// for one.
+type LockPostgres struct {
+	config *pgx.ConnConfig
+	log    *slog.Logger
+
+	// mu guards session, which can't be used concurrently
+	mu      sync.Mutex
+	session *pgx.Conn
+}
+
+// lockTimeout bounds the lock statements. They run without the caller's
+// cancellation, since pgx closes a connection whose query is cancelled and
+// that would drop the locks of every other running job.
+const lockTimeout = 5 * time.Second
+
+func (lp *LockPostgres) WithLock(ctx context.Context, name string, fn func(ctx context.Context)) (bool, error) {
+	locked, err := lp.tryLock(ctx, name)
+	if err != nil || !locked {
+		return false, err
+	}
+
+	defer lp.unlock(ctx, name)
+
+	fn(ctx)
+
+	return true, nil
+}
+
+func (lp *LockPostgres) tryLock(ctx context.Context, name string) (bool, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	qctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockTimeout)
+	defer cancel()
+
+	// a lost session took its locks with it, so a new one starts clean
+	if lp.session == nil || lp.session.IsClosed() {
+		session, err := pgx.ConnectConfig(qctx, lp.config)
+		if err != nil {
+			return false, err
+		}
+
+		lp.session = session
+	}
+
+	var locked bool
+
+	if err := lp.session.QueryRow(qctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&locked); err != nil {
+		return false, err
+	}
+
+	return locked, nil
+}
+
+func (lp *LockPostgres) unlock(ctx context.Context, name string) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.session == nil || lp.session.IsClosed() {
+		return
+	}
+
+	qctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockTimeout)
+	defer cancel()
+
+	if _, err := lp.session.Exec(qctx, "SELECT pg_advisory_unlock(hashtext($1))", name); err != nil {
+		lp.log.ErrorContext(ctx, "Failed to release advisory lock",
+			slog.String("lock", name), slog.Any("error", err))
+
+		// closing the session is the only other way to drop the lock
+		_ = lp.session.Close(qctx)
+		lp.session = nil
+	}
+}
+
+// Close ends the lock session, releasing any locks still held.
+func (lp *LockPostgres) Close(ctx context.Context) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.session == nil {
+		return nil
+	}
+
+	err := lp.session.Close(ctx)
+	lp.session = nil
+
+	return err
+}
+
+// NewLockPostgres connects the lock session lazily, with the settings of
+// the pool's connections.
+func NewLockPostgres(conn *pgxpool.Pool, log *slog.Logger) *LockPostgres {
+	return &LockPostgres{
+		config: conn.Config().ConnConfig.Copy(),
+		log:    log,
+	}
+}
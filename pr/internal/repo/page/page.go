@@ -0,0 +1,118 @@
+package page
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Request asks for one page of a list ordered by (created_at, id).
+type Request struct {
+	Cursor string
+	Limit  int
+	Desc   bool
+}
+
+// Info describes where the returned page ends.
+type Info struct {
+	NextCursor string
+	Limit      int
+	HasMore    bool
+}
+
+// Cursor is the keyset position of the last returned item. It is handed
+// to clients as an opaque string.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Desc      bool      `json:"d,omitempty"`
+}
+
+func (r Request) limit() int {
+	if r.Limit <= 0 {
+		return DefaultLimit
+	}
+
+	return min(r.Limit, MaxLimit)
+}
+
+// Fetch is the number of rows to query: one more than the page size,
+// so the extra row tells whether there is a next page.
+func (r Request) Fetch() int {
+	return r.limit() + 1
+}
+
+// After decodes the request cursor. It returns nil for the first page.
+func (r Request) After() (*Cursor, error) {
+	if r.Cursor == "" {
+		return nil, nil //nolint:nilnil // no cursor means first page
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+	if err != nil {
+		return nil, invalidCursor()
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" || c.Desc != r.Desc {
+		return nil, invalidCursor()
+	}
+
+	return &c, nil
+}
+
+// Where returns the condition selecting rows after the cursor, with
+// placeholders starting at $n.
+func (c *Cursor) Where(createdCol, idCol string, n int) (string, []any) {
+	op := ">"
+	if c.Desc {
+		op = "<"
+	}
+
+	return fmt.Sprintf("(%s, %s) %s ($%d, $%d)", createdCol, idCol, op, n, n+1), []any{c.CreatedAt, c.ID}
+}
+
+// OrderBy returns the ORDER BY clause matching the keyset.
+func (r Request) OrderBy(createdCol, idCol string) string {
+	dir := "ASC"
+	if r.Desc {
+		dir = "DESC"
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s, %s %s", createdCol, dir, idCol, dir)
+}
+
+// Slice trims the extra row fetched by Fetch and builds the page info.
+func Slice[T any](r Request, rows []T, cursorOf func(T) (time.Time, string)) ([]T, Info) {
+	info := Info{Limit: r.limit()}
+
+	if len(rows) <= info.Limit {
+		return rows, info
+	}
+
+	rows = rows[:info.Limit]
+	createdAt, id := cursorOf(rows[len(rows)-1])
+
+	raw, _ := json.Marshal(Cursor{CreatedAt: createdAt, ID: id, Desc: r.Desc})
+
+	info.HasMore = true
+	info.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+
+	return rows, info
+}
+
+func invalidCursor() error {
+	return apperrors.NewValidationError([]apperrors.FieldError{{
+		Field:   "cursor",
+		Code:    "INVALID_FORMAT",
+		Message: "cursor is malformed or does not match the requested sort",
+	}})
+}
@@ -2,11 +2,36 @@ package pr
 
 import (
 	"context"
+	"pr/internal/repo/page"
 )
 
 type PRInterface interface {
-	CreatePR(ctx context.Context, prm PRModel) ([]string, error)
-	MergePR(ctx context.Context, id string) (*PRWithDateModel, []string, error)
+	CreatePR(ctx context.Context, prm PRModel) (PRModel, AssignResult, error)
+	// MergePR fails with MERGE_BLOCKED while the author team's merge policy
+	// is not met, unless override is set. Merging a merged PR is a no-op.
+	MergePR(ctx context.Context, id string, override bool, expectedVersion *int64) (*PRWithDateModel, MergeResult, error)
+	GetPR(ctx context.Context, id string) (*PRWithDateModel, []string, error)
+	GetPRHistory(ctx context.Context, id string) ([]HistoryModel, error)
+	ListPRs(ctx context.Context, filter ListFilter, p page.Request) ([]PRWithReviewersModel, page.Info, error)
 	GetPrReviewers(ctx context.Context, id string) ([]string, error)
-	SwapPRReviewer(ctx context.Context, prID, userID string) (PRModel, []string, string, error)
+	SwapPRReviewer(ctx context.Context, req SwapRequest, expectedVersion *int64) (PRModel, AssignResult, error)
+	AddPRReviewer(ctx context.Context, prID, userID string, expectedVersion *int64) (PRModel, []string, error)
+	RemovePRReviewer(ctx context.Context, prID, userID string, expectedVersion *int64) (PRModel, []string, error)
+	GetPRReviews(ctx context.Context, id string) ([]ReviewModel, error)
+	// SetReviewState updates a reviewer's assignment. A decline replaces the
+	// reviewer like SwapPRReviewer does and reports the pick in AssignResult.
+	SetReviewState(ctx context.Context, req ReviewStateRequest, expectedVersion *int64) (PRModel, AssignResult, error)
+	// FillOpenSlots assigns reviewers to open PRs short of RequiredReviewers,
+	// picking them like CreatePR does. PRs whose team role rule can't be
+	// met yet stay queued. A non-empty teamName limits it to PRs that can
+	// draw from that team.
+	FillOpenSlots(ctx context.Context, teamName string) ([]SlotFill, error)
+	// ProcessStaleReviews reminds reviewers of reviews past their team's
+	// reminder threshold and reassigns or escalates those past the
+	// escalation threshold. Each review is reminded and escalated once.
+	ProcessStaleReviews(ctx context.Context) ([]SLAEvent, error)
+	// ClearReminder unmarks a review reminded by ProcessStaleReviews, so
+	// the next run reminds the reviewer again. It is for reminders that
+	// could not be sent.
+	ClearReminder(ctx context.Context, prID, reviewerID string) error
 }
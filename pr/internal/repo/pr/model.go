@@ -7,6 +7,13 @@ type PRModel struct {
 	Name     string
 	AuthorID string
 	Status   string
+	// ChangedFiles steer reviewer selection on creation towards the code
+	// owners of the files; they are not stored.
+	ChangedFiles []string
+	// Labels are tag names; reviewers whose skills match them are
+	// required or preferred depending on the mode of each tag.
+	Labels  []string
+	Version int64
 }
 
 type PRWithDateModel struct {
@@ -16,4 +23,222 @@ type PRWithDateModel struct {
 	Status    string
 	CreatedAt *time.Time
 	MergedAt  *time.Time
+	Labels    []string
+	Version   int64
+}
+
+// MergeResult is the outcome of a merge.
+type MergeResult struct {
+	Reviewers []string
+	// Merged is set when this call moved the PR from OPEN to MERGED, and
+	// unset when the PR had been merged already.
+	Merged bool
+}
+
+// RequiredReviewers is the number of reviewers every PR should have.
+const RequiredReviewers = 2
+
+// team reviewer policies: what CreatePR does when it can't find
+// RequiredReviewers candidates
+const (
+	// create the PR and queue the open slots for the filler
+	PolicyAllowPartial = "allow_partial"
+	// fail with NOT_ENOUGH_REVIEWERS
+	PolicyRequireFull = "require_full"
+)
+
+// history events
+const (
+	EventCreated            = "CREATED"
+	EventReviewerAssigned   = "REVIEWER_ASSIGNED"
+	EventReviewerReassigned = "REVIEWER_REASSIGNED"
+	EventReviewerRemoved    = "REVIEWER_REMOVED"
+	EventMerged             = "MERGED"
+	// the reviewer moved their assignment to a new review state
+	EventReviewAccepted   = "REVIEW_ACCEPTED"
+	EventReviewDeclined   = "REVIEW_DECLINED"
+	EventReviewApproved   = "REVIEW_APPROVED"
+	EventChangesRequested = "CHANGES_REQUESTED"
+	// a stale review was escalated to the team lead
+	EventReviewEscalated = "REVIEW_ESCALATED"
+)
+
+// history reasons set by the service itself
+const (
+	ReasonTeamMove    = "TEAM_MOVE"
+	ReasonUserDeleted = "USER_DELETED"
+	// the reviewer was removed from the author's team
+	ReasonTeamRemoved = "TEAM_REMOVED"
+	// the reviewer's unavailability window started
+	ReasonUserUnavailable = "USER_UNAVAILABLE"
+	// the reviewer was drawn from one of the author team's fallback teams
+	ReasonFallbackTeam = "FALLBACK_TEAM"
+	// the reviewer was added or removed by hand
+	ReasonManual = "MANUAL"
+	// the previous reviewer declined the review
+	ReasonDeclined = "REVIEWER_DECLINED"
+	// the PR was merged despite unmet merge policy conditions
+	ReasonMergeOverride = "MERGE_OVERRIDE"
+	// the review outlived the team's escalation threshold
+	ReasonSLAExpired = "SLA_EXPIRED"
+	// the reviewer owns some of the PR's changed files
+	ReasonCodeOwner = "CODE_OWNER"
+	// the reviewer has a skill one of the PR's required labels asks for
+	ReasonRequiredSkill = "REQUIRED_SKILL"
+	// the reviewer was picked to meet the team's role rule
+	ReasonRoleRule = "ROLE_RULE"
+)
+
+// what happens to a review past the team's escalation threshold
+const (
+	// hand it to another candidate; escalate if there is none
+	EscalationReassign = "reassign"
+	// leave it and notify the team lead
+	EscalationEscalate = "escalate"
+)
+
+// SLA actions reported by ProcessStaleReviews
+const (
+	SLAReminder   = "REMINDER"
+	SLAReassigned = "REASSIGNED"
+	SLAEscalated  = "ESCALATED"
+)
+
+// SLAEvent is one action taken on a stale review. ReplacedBy is set for
+// SLAReassigned and LeadID, if the team has a lead, for SLAEscalated.
+type SLAEvent struct {
+	AssignedAt time.Time
+	PRID       string
+	ReviewerID string
+	Action     string
+	ReplacedBy string
+	LeadID     string
+}
+
+// merge policy conditions reported in MERGE_BLOCKED details
+const (
+	ConditionMinApprovals       = "min_approvals"
+	ConditionNoChangesRequested = "no_changes_requested"
+)
+
+// review states of a single assignment
+const (
+	ReviewPending          = "PENDING"
+	ReviewAccepted         = "ACCEPTED"
+	ReviewDeclined         = "DECLINED"
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+)
+
+// reviewStateEvents maps the states a reviewer can move to onto the
+// history event recorded for the move.
+var reviewStateEvents = map[string]string{
+	ReviewAccepted:         EventReviewAccepted,
+	ReviewDeclined:         EventReviewDeclined,
+	ReviewApproved:         EventReviewApproved,
+	ReviewChangesRequested: EventChangesRequested,
+}
+
+// ReviewStateEvent returns the history event for moving to state.
+func ReviewStateEvent(state string) string {
+	return reviewStateEvents[state]
+}
+
+// CanMoveReview reports whether an assignment may go from one review
+// state to another. A review is accepted or declined before a verdict;
+// a verdict can be changed but not taken back.
+func CanMoveReview(from, to string) bool {
+	switch to {
+	case ReviewAccepted:
+		return from == ReviewPending
+	case ReviewDeclined:
+		return from == ReviewPending || from == ReviewAccepted
+	case ReviewApproved, ReviewChangesRequested:
+		return from != to
+	}
+
+	return false
+}
+
+// ReviewModel is the state of one reviewer's assignment.
+type ReviewModel struct {
+	UpdatedAt time.Time
+	UserID    string
+	State     string
+}
+
+// ReviewStateRequest moves a reviewer's assignment to State. Reason is
+// free text kept in the history.
+type ReviewStateRequest struct {
+	PRID   string
+	UserID string
+	State  string
+	Reason string
+}
+
+// SwapRequest describes a reassignment. With an empty NewReviewerID a
+// random candidate is picked, skipping everyone in Exclude.
+type SwapRequest struct {
+	PRID          string
+	OldReviewerID string
+	NewReviewerID string
+	Exclude       []string
+	// free text kept in the history; empty means the default reason
+	Reason string
+}
+
+type HistoryModel struct {
+	CreatedAt time.Time
+	Event     string
+	UserID    string
+	OldUserID string
+	Reason    string
+	ID        int64
+}
+
+// ListFilter narrows the PR search. Zero values match everything.
+type ListFilter struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	TeamName      string
+	AuthorID      string
+	ReviewerID    string
+	Status        string
+	NameQuery     string
+}
+
+type PRWithReviewersModel struct {
+	PRWithDateModel
+	Reviewers []string
+}
+
+// AssignmentPolicy configures reviewer selection.
+type AssignmentPolicy struct {
+	// ExceedCapacity lets reviewers at capacity be picked when nobody else
+	// is available, instead of failing with NO_CAPACITY.
+	ExceedCapacity bool
+}
+
+// AssignResult is the outcome of picking reviewers for a PR.
+type AssignResult struct {
+	Reviewers []string
+	// FallbackReviewers are the newly assigned reviewers that come from a
+	// fallback team rather than the author's team.
+	FallbackReviewers []string
+	// CodeOwners are the newly assigned reviewers picked as code owners
+	// of the PR's changed files.
+	CodeOwners []string
+	// SkillReviewers are the newly assigned reviewers picked to cover the
+	// PR's required labels.
+	SkillReviewers   []string
+	ReplacedBy       string
+	UnfilledSlots    int
+	CapacityExceeded bool
+}
+
+// SlotFill reports reviewers assigned to a PR's open slots.
+type SlotFill struct {
+	PRID          string
+	Reviewers     []string
+	UnfilledSlots int
 }
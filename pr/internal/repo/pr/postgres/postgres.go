@@ -3,114 +3,318 @@ package prpostgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	apperrors "pr/internal/app/errors"
+	"pr/internal/repo"
+	"pr/internal/repo/assign"
+	"pr/internal/repo/page"
 	"pr/internal/repo/pr"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// prLabels selects the labels of the PR (aliased pr) as a sorted array.
+const prLabels = `ARRAY(
+			SELECT l.tag FROM pull_request_labels AS l WHERE l.pull_request_id = pr.id ORDER BY l.tag
+		  )`
+
 type PRPostgres struct {
-	conn *pgxpool.Pool
-	log  *slog.Logger
+	conn   *pgxpool.Pool
+	log    *slog.Logger
+	policy pr.AssignmentPolicy
 }
 
-func (prp *PRPostgres) CreatePR(ctx context.Context, prm pr.PRModel) ([]string, error) {
+func (prp *PRPostgres) CreatePR(ctx context.Context, prm pr.PRModel) (pr.PRModel, pr.AssignResult, error) {
 	tx, err := prp.conn.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
 	defer func() {
 		_ = tx.Rollback(ctx)
 	}()
 
-	var authorTeam string
-	if err = tx.QueryRow(ctx, "SELECT team_name FROM users WHERE id = $1", prm.AuthorID).Scan(&authorTeam); err != nil {
+	var authorTeam, reviewerPolicy string
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(u.team_name, ''), COALESCE(t.reviewer_policy, $2)
+		FROM users AS u
+			LEFT JOIN teams AS t
+				ON t.name = u.team_name
+		WHERE u.id = $1 AND u.deleted_at IS NULL`,
+		prm.AuthorID, pr.PolicyAllowPartial).Scan(&authorTeam, &reviewerPolicy); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apperrors.ErrNotFound
+			return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrNotFound
 		}
 
-		return nil, err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	var reviewers []string
+	required, err := assign.RequiredLabels(ctx, tx, prm.Labels)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
 
-	rows, err := tx.Query(ctx, `
-		SELECT id
-		FROM users
-		WHERE team_name = $1
-		  AND id != $2
-		  AND is_active = true
-		ORDER BY RANDOM()
-		LIMIT 2`,
-		authorTeam, prm.AuthorID)
+	rule, err := assign.TeamRoleRule(ctx, tx, authorTeam)
 	if err != nil {
-		return nil, err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
+	skilled, skilledExceeded, err := assign.PickSkilled(ctx, tx, authorTeam, prm, required, rule.Roles,
+		pr.RequiredReviewers, prp.policy)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	skilledIDs := assign.CandidateIDs(skilled)
 
-		reviewers = append(reviewers, id)
+	ranked, rankedExceeded, err := assign.PickByRole(ctx, tx, authorTeam, prm, rule, skilled,
+		pr.RequiredReviewers-len(skilled), prp.policy)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO pull_requests (id, name, author_id, status)
-		VALUES ($1, $2, $3, $4)`,
-		prm.ID, prm.Name, prm.AuthorID, prm.Status)
+	rankedIDs := assign.CandidateIDs(ranked)
+
+	owners, err := assign.PickOwners(ctx, tx, authorTeam, prm, pr.RequiredReviewers-len(skilled)-len(ranked),
+		slices.Concat(skilledIDs, rankedIDs))
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	ownerIDs := assign.CandidateIDs(owners)
+	reserved := slices.Concat(skilledIDs, rankedIDs, ownerIDs)
+
+	// the reserved reviewers took some slots, so running out of capacity
+	// for the rest leaves them unfilled rather than failing
+	picked, exceeded, err := assign.PickNewReviewers(ctx, tx, authorTeam, prm, pr.RequiredReviewers-len(reserved),
+		reserved, prp.policy)
+	if err != nil && (len(reserved) == 0 || !errors.Is(err, apperrors.ErrNoCapacity)) {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	picked = slices.Concat(skilled, ranked, owners, picked)
+
+	if len(picked) < pr.RequiredReviewers && reviewerPolicy == pr.PolicyRequireFull {
+		return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrNotEnoughReviewers
+	}
+
+	res := pr.AssignResult{
+		CodeOwners:       ownerIDs,
+		SkillReviewers:   skilledIDs,
+		CapacityExceeded: exceeded || skilledExceeded || rankedExceeded,
+		UnfilledSlots:    pr.RequiredReviewers - len(picked),
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO pull_requests (id, name, author_id, status, unfilled_slots)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING version`,
+		prm.ID, prm.Name, prm.AuthorID, prm.Status, res.UnfilledSlots).Scan(&prm.Version)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			if pgErr.Code == "23505" {
-				return nil, apperrors.ErrPRExists
+				return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrPRExists
 			}
 		}
 
-		return nil, err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	for _, reviewerID := range reviewers {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO users_pull_requests (pull_requests_id, users_id)
-			VALUES ($1, $2)`,
-			prm.ID, reviewerID)
-		if err != nil {
-			return nil, err
+	if len(prm.Labels) > 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO pull_request_labels (pull_request_id, tag)
+			SELECT $1, unnest($2::text[])`,
+			prm.ID, prm.Labels); err != nil {
+			return pr.PRModel{}, pr.AssignResult{}, err
 		}
 	}
 
+	if err := assign.AddHistory(ctx, tx, prm.ID, pr.EventCreated, prm.AuthorID, "", ""); err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	res.Reviewers, res.FallbackReviewers, err = assign.AssignReviewers(ctx, tx, prm.ID, picked)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	return reviewers, nil
+	return prm, res, nil
 }
 
-func (prp *PRPostgres) MergePR(ctx context.Context, id string) (*pr.PRWithDateModel, []string, error) {
-	row := prp.conn.QueryRow(ctx, `
+func (prp *PRPostgres) MergePR(ctx context.Context, id string, override bool,
+	expectedVersion *int64) (*pr.PRWithDateModel, pr.MergeResult, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return nil, pr.MergeResult{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var (
+		version int64
+		status  string
+	)
+
+	err = tx.QueryRow(ctx, `SELECT version, status FROM pull_requests WHERE id = $1 FOR UPDATE`, id).
+		Scan(&version, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pr.MergeResult{}, apperrors.ErrNotFound
+		}
+
+		return nil, pr.MergeResult{}, err
+	}
+
+	if !repo.VersionMatches(expectedVersion, version) {
+		return nil, pr.MergeResult{}, apperrors.ErrPreconditionFailed
+	}
+
+	var blockers []apperrors.FieldError
+
+	if status != "MERGED" {
+		blockers, err = mergeBlockers(ctx, tx, id)
+		if err != nil {
+			return nil, pr.MergeResult{}, err
+		}
+
+		if len(blockers) > 0 && !override {
+			return nil, pr.MergeResult{}, apperrors.NewMergeBlockedError(blockers)
+		}
+	}
+
+	// merging an already merged PR is a no-op and keeps the version
+	row := tx.QueryRow(ctx, `
 		UPDATE pull_requests 
-		SET status = 'MERGED' 
+		SET status = 'MERGED',
+			version = version + CASE WHEN status = 'MERGED' THEN 0 ELSE 1 END
 		WHERE id = $1 
-		RETURNING id, name, author_id, status, created_at, merged_at`,
+		RETURNING id, name, author_id, status, created_at, merged_at, version`,
 		id)
 
 	var prModel pr.PRWithDateModel
-	err := row.Scan(
+	err = row.Scan(
 		&prModel.ID,
 		&prModel.Name,
 		&prModel.AuthorID,
 		&prModel.Status,
 		&prModel.CreatedAt,
 		&prModel.MergedAt,
+		&prModel.Version,
 	)
 
+	if err != nil {
+		return nil, pr.MergeResult{}, err
+	}
+
+	if status != "MERGED" {
+		var reason string
+		if len(blockers) > 0 {
+			reason = pr.ReasonMergeOverride
+		}
+
+		if err := assign.AddHistory(ctx, tx, id, pr.EventMerged, "", "", reason); err != nil {
+			return nil, pr.MergeResult{}, err
+		}
+	}
+
+	// the row lock makes this the only call that sees the PR open
+	res := pr.MergeResult{Merged: status != "MERGED"}
+
+	res.Reviewers, err = getPrReviewers(ctx, tx, id)
+	if err != nil {
+		return nil, pr.MergeResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, pr.MergeResult{}, err
+	}
+
+	return &prModel, res, nil
+}
+
+// mergeBlockers checks the PR against the merge policy of its author's
+// team and returns one entry per unmet condition.
+func mergeBlockers(ctx context.Context, q querier, prID string) ([]apperrors.FieldError, error) {
+	var (
+		minApprovals, approvals int
+		blockOnChanges          bool
+		changesRequested        []string
+	)
+
+	err := q.QueryRow(ctx, `
+		SELECT COALESCE(t.min_approvals, 0),
+			COALESCE(t.block_on_changes_requested, false),
+			(SELECT COUNT(*) FROM users_pull_requests
+				WHERE pull_requests_id = p.id AND state = 'APPROVED'),
+			COALESCE((SELECT array_agg(users_id ORDER BY users_id) FROM users_pull_requests
+				WHERE pull_requests_id = p.id AND state = 'CHANGES_REQUESTED'), '{}')
+		FROM pull_requests AS p
+			JOIN users AS a
+				ON a.id = p.author_id
+			LEFT JOIN teams AS t
+				ON t.name = a.team_name
+		WHERE p.id = $1`,
+		prID).Scan(&minApprovals, &blockOnChanges, &approvals, &changesRequested)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockers []apperrors.FieldError
+
+	if approvals < minApprovals {
+		blockers = append(blockers, apperrors.FieldError{
+			Field:   pr.ConditionMinApprovals,
+			Code:    "UNMET",
+			Message: fmt.Sprintf("%d of %d required approvals", approvals, minApprovals),
+		})
+	}
+
+	if blockOnChanges && len(changesRequested) > 0 {
+		blockers = append(blockers, apperrors.FieldError{
+			Field:   pr.ConditionNoChangesRequested,
+			Code:    "UNMET",
+			Message: "changes requested by " + strings.Join(changesRequested, ", "),
+		})
+	}
+
+	return blockers, nil
+}
+
+func (prp *PRPostgres) GetPR(ctx context.Context, id string) (*pr.PRWithDateModel, []string, error) {
+	var prModel pr.PRWithDateModel
+
+	err := prp.conn.QueryRow(ctx, `
+		SELECT id, name, author_id, status, created_at, merged_at, version, `+prLabels+`
+		FROM pull_requests AS pr
+		WHERE id = $1`, id).Scan(
+		&prModel.ID,
+		&prModel.Name,
+		&prModel.AuthorID,
+		&prModel.Status,
+		&prModel.CreatedAt,
+		&prModel.MergedAt,
+		&prModel.Version,
+		&prModel.Labels,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil, apperrors.ErrNotFound
@@ -128,6 +332,10 @@ func (prp *PRPostgres) MergePR(ctx context.Context, id string) (*pr.PRWithDateMo
 }
 
 func (prp *PRPostgres) GetPrReviewers(ctx context.Context, id string) ([]string, error) {
+	return getPrReviewers(ctx, prp.conn, id)
+}
+
+func getPrReviewers(ctx context.Context, q querier, id string) ([]string, error) {
 	var usersID []string
 
 	sql := `
@@ -139,7 +347,7 @@ func (prp *PRPostgres) GetPrReviewers(ctx context.Context, id string) ([]string,
 			ON upr.pull_requests_id = pr.id
 	WHERE pr.id = $1`
 
-	rows, err := prp.conn.Query(ctx, sql, id)
+	rows, err := q.Query(ctx, sql, id)
 	if err != nil {
 		return nil, err
 	}
@@ -157,10 +365,13 @@ func (prp *PRPostgres) GetPrReviewers(ctx context.Context, id string) ([]string,
 	return usersID, nil
 }
 
-func (prp *PRPostgres) SwapPRReviewer(ctx context.Context, prID, userID string) (pr.PRModel, []string, string, error) {
+func (prp *PRPostgres) SwapPRReviewer(ctx context.Context, req pr.SwapRequest,
+	expectedVersion *int64) (pr.PRModel, pr.AssignResult, error) {
+	prID, userID := req.PRID, req.OldReviewerID
+
 	tx, err := prp.conn.Begin(ctx)
 	if err != nil {
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
 	defer func() {
@@ -175,119 +386,827 @@ func (prp *PRPostgres) SwapPRReviewer(ctx context.Context, prID, userID string)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pr.PRModel{}, nil, "", apperrors.ErrNotFound
+			return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrNotFound
 		}
 
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	bodyPr, err := lockOpenPR(ctx, tx, prID, expectedVersion)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	var bodyPr pr.PRModel
+	var assigned int
 
 	err = tx.QueryRow(ctx, `
-		SELECT id, name, author_id, status
-		FROM pull_requests
-		WHERE id = $1`, prID).Scan(
-		&bodyPr.ID, &bodyPr.Name, &bodyPr.AuthorID, &bodyPr.Status)
+		SELECT 1
+		FROM users_pull_requests
+		WHERE pull_requests_id = $1 AND users_id = $2`,
+		prID, userID).Scan(&assigned)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pr.PRModel{}, nil, "", apperrors.ErrNotFound
+			return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrNotAssigned
 		}
 
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	if bodyPr.Status == "MERGED" {
-		return pr.PRModel{}, nil, "", apperrors.ErrMerged
+	replacement, err := assign.ReplaceReviewer(ctx, tx, bodyPr, userID, assign.ReplaceOptions{
+		NewReviewerID: req.NewReviewerID,
+		Exclude:       req.Exclude,
+		Reason:        req.Reason,
+	}, prp.policy)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	var assigned int
+	bodyPr.Version = replacement.Version
 
-	err = tx.QueryRow(ctx, `
-		SELECT 1
-		FROM users_pull_requests
-		WHERE pull_requests_id = $1 AND users_id = $2`,
-		prID, userID).Scan(&assigned)
+	var newReviewers []string
 
+	rows, err := tx.Query(ctx, `
+		SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $1`, prID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return pr.PRModel{}, nil, "", apperrors.ErrNotAssigned
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return pr.PRModel{}, pr.AssignResult{}, err
 		}
 
-		return pr.PRModel{}, nil, "", err
+		newReviewers = append(newReviewers, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	res := pr.AssignResult{
+		Reviewers:        newReviewers,
+		ReplacedBy:       replacement.ReviewerID,
+		CapacityExceeded: replacement.CapacityExceeded,
+	}
+
+	if replacement.FromFallback {
+		res.FallbackReviewers = []string{replacement.ReviewerID}
+	}
+
+	return bodyPr, res, nil
+}
+
+// AddPRReviewer assigns a specific user to the PR. The user has to pass
+// the same checks as a hand-picked replacement (see assign.CheckTarget
+// and assign.CheckAddition).
+func (prp *PRPostgres) AddPRReviewer(ctx context.Context, prID, userID string,
+	expectedVersion *int64) (pr.PRModel, []string, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	prm, err := lockOpenPR(ctx, tx, prID, expectedVersion)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	teamName, err := assign.AuthorTeam(ctx, tx, prm.AuthorID)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	target, err := assign.CheckTarget(ctx, tx, prm, teamName, userID)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	reviewers, err := getPrReviewers(ctx, tx, prID)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if len(reviewers) >= pr.RequiredReviewers {
+		return pr.PRModel{}, nil, apperrors.ErrTooManyReviewers
+	}
+
+	if err := assign.CheckAddition(ctx, tx, prID, teamName, target, len(reviewers), prp.policy); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO users_pull_requests (pull_requests_id, users_id)
+		VALUES ($1, $2)`,
+		prID, userID); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if err := assign.AddHistory(ctx, tx, prID, pr.EventReviewerAssigned, userID, "", pr.ReasonManual); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	// a manually picked reviewer takes one of the slots the filler waits for
+	if err := tx.QueryRow(ctx, `
+		UPDATE pull_requests
+		SET version = version + 1, unfilled_slots = GREATEST(unfilled_slots - 1, 0)
+		WHERE id = $1
+		RETURNING version`,
+		prID).Scan(&prm.Version); err != nil {
+		return pr.PRModel{}, nil, err
 	}
 
-	var teamName string
+	if err := tx.Commit(ctx); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	return prm, append(reviewers, userID), nil
+}
+
+// RemovePRReviewer unassigns the reviewer without picking a replacement.
+// It refuses to drop a reviewer the team's role rule still needs.
+func (prp *PRPostgres) RemovePRReviewer(ctx context.Context, prID, userID string,
+	expectedVersion *int64) (pr.PRModel, []string, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	prm, err := lockOpenPR(ctx, tx, prID, expectedVersion)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	reviewers, err := getPrReviewers(ctx, tx, prID)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if !slices.Contains(reviewers, userID) {
+		return pr.PRModel{}, nil, apperrors.ErrNotAssigned
+	}
+
+	teamName, err := assign.AuthorTeam(ctx, tx, prm.AuthorID)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if err := assign.CheckRemoval(ctx, tx, prID, teamName, userID); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	// the author dropped the reviewer on purpose, so the slot is not queued
+	prm.Version, err = assign.RemoveReviewer(ctx, tx, prID, userID, pr.ReasonManual, false)
+	if err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pr.PRModel{}, nil, err
+	}
+
+	return prm, slices.DeleteFunc(reviewers, func(id string) bool { return id == userID }), nil
+}
 
-	if err = tx.QueryRow(ctx, "SELECT team_name FROM users WHERE id = $1", bodyPr.AuthorID).Scan(&teamName); err != nil {
+// lockOpenPR locks the PR row for a reviewer change and checks its
+// version. Reviewers of a merged PR can't change.
+func lockOpenPR(ctx context.Context, tx pgx.Tx, prID string, expectedVersion *int64) (pr.PRModel, error) {
+	var prm pr.PRModel
+
+	err := tx.QueryRow(ctx, `
+		SELECT id, name, author_id, status, version
+		FROM pull_requests
+		WHERE id = $1
+		FOR UPDATE`, prID).Scan(
+		&prm.ID, &prm.Name, &prm.AuthorID, &prm.Status, &prm.Version)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pr.PRModel{}, nil, "", apperrors.ErrNotFound
+			return pr.PRModel{}, apperrors.ErrNotFound
 		}
 
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, err
 	}
 
-	var newReviewerID string
-	err = tx.QueryRow(ctx, `
-		SELECT u.id
-		FROM users u
-		WHERE u.team_name = $1
-		  AND u.is_active = true
-		  AND u.id != $2
-		  AND u.id != $3
-		  AND u.id NOT IN (
-		      SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $4
-			  	AND users_id != $2
-		  )
-		ORDER BY RANDOM()
-		LIMIT 1`,
-		teamName, userID, bodyPr.AuthorID, prID).Scan(&newReviewerID)
+	if !repo.VersionMatches(expectedVersion, prm.Version) {
+		return pr.PRModel{}, apperrors.ErrPreconditionFailed
+	}
+
+	if prm.Status == "MERGED" {
+		return pr.PRModel{}, apperrors.ErrMerged
+	}
+
+	return prm, nil
+}
+
+func (prp *PRPostgres) GetPRHistory(ctx context.Context, id string) ([]pr.HistoryModel, error) {
+	rows, err := prp.conn.Query(ctx, `
+		SELECT id, event, COALESCE(user_id, ''), COALESCE(old_user_id, ''), COALESCE(reason, ''), created_at
+		FROM pull_request_history
+		WHERE pull_request_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []pr.HistoryModel
+
+	for rows.Next() {
+		var h pr.HistoryModel
+		if err := rows.Scan(&h.ID, &h.Event, &h.UserID, &h.OldUserID, &h.Reason, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
+
+func (prp *PRPostgres) GetPRReviews(ctx context.Context, id string) ([]pr.ReviewModel, error) {
+	rows, err := prp.conn.Query(ctx, `
+		SELECT users_id, state, state_updated_at
+		FROM users_pull_requests
+		WHERE pull_requests_id = $1
+		ORDER BY users_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []pr.ReviewModel
+
+	for rows.Next() {
+		var r pr.ReviewModel
+		if err := rows.Scan(&r.UserID, &r.State, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, r)
+	}
+
+	return reviews, rows.Err()
+}
+
+// SetReviewState records the reviewer's move to req.State. A declined
+// reviewer is remembered so no later pick chooses them for this PR, and is
+// replaced right away; without a candidate the slot goes to the filler.
+func (prp *PRPostgres) SetReviewState(ctx context.Context, req pr.ReviewStateRequest,
+	expectedVersion *int64) (pr.PRModel, pr.AssignResult, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
+	prm, err := lockOpenPR(ctx, tx, req.PRID, expectedVersion)
 	if err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	var state string
+
+	if err := tx.QueryRow(ctx, `
+		SELECT state
+		FROM users_pull_requests
+		WHERE pull_requests_id = $1 AND users_id = $2`,
+		req.PRID, req.UserID).Scan(&state); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pr.PRModel{}, nil, "", apperrors.ErrNoCandidate
+			return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrNotAssigned
 		}
 
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	_, err = tx.Exec(ctx, `
-		UPDATE users_pull_requests
-		SET users_id = $1
-		WHERE pull_requests_id = $2 AND users_id = $3`,
-		newReviewerID, prID, userID)
+	if !pr.CanMoveReview(state, req.State) {
+		return pr.PRModel{}, pr.AssignResult{}, apperrors.ErrInvalidReviewState
+	}
+
+	if err := assign.AddHistory(ctx, tx, req.PRID, pr.ReviewStateEvent(req.State), req.UserID, "",
+		req.Reason); err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
+
+	var res pr.AssignResult
+
+	if req.State == pr.ReviewDeclined {
+		res, prm.Version, err = prp.replaceDecliner(ctx, tx, prm, req.UserID)
+		if err != nil {
+			return pr.PRModel{}, pr.AssignResult{}, err
+		}
+	} else {
+		if _, err := tx.Exec(ctx, `
+			UPDATE users_pull_requests
+			SET state = $3, state_updated_at = NOW()
+			WHERE pull_requests_id = $1 AND users_id = $2`,
+			req.PRID, req.UserID, req.State); err != nil {
+			return pr.PRModel{}, pr.AssignResult{}, err
+		}
+
+		if err := tx.QueryRow(ctx, `
+			UPDATE pull_requests SET version = version + 1 WHERE id = $1 RETURNING version`,
+			req.PRID).Scan(&prm.Version); err != nil {
+			return pr.PRModel{}, pr.AssignResult{}, err
+		}
+	}
+
+	res.Reviewers, err = getPrReviewers(ctx, tx, req.PRID)
 	if err != nil {
-		return pr.PRModel{}, nil, "", err
+		return pr.PRModel{}, pr.AssignResult{}, err
 	}
 
-	var newReviewers []string
+	if err := tx.Commit(ctx); err != nil {
+		return pr.PRModel{}, pr.AssignResult{}, err
+	}
 
-	rows, err := tx.Query(ctx, `
-		SELECT users_id FROM users_pull_requests WHERE pull_requests_id = $1`, prID)
+	return prm, res, nil
+}
+
+// replaceDecliner remembers the decline and swaps the reviewer for a new
+// candidate, or drops them and queues the slot when there is none.
+func (prp *PRPostgres) replaceDecliner(ctx context.Context, tx pgx.Tx, prm pr.PRModel,
+	userID string) (pr.AssignResult, int64, error) {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO pull_request_declines (pull_request_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		prm.ID, userID); err != nil {
+		return pr.AssignResult{}, 0, err
+	}
+
+	replacement, err := assign.ReplaceReviewer(ctx, tx, prm, userID,
+		assign.ReplaceOptions{Reason: pr.ReasonDeclined}, prp.policy)
 	if err != nil {
-		return pr.PRModel{}, nil, "", err
+		if !assign.Unfillable(err) {
+			return pr.AssignResult{}, 0, err
+		}
+
+		version, err := assign.RemoveReviewer(ctx, tx, prm.ID, userID, pr.ReasonDeclined, true)
+		if err != nil {
+			return pr.AssignResult{}, 0, err
+		}
+
+		return pr.AssignResult{UnfilledSlots: 1}, version, nil
+	}
+
+	res := pr.AssignResult{
+		ReplacedBy:       replacement.ReviewerID,
+		CapacityExceeded: replacement.CapacityExceeded,
+	}
+
+	if replacement.FromFallback {
+		res.FallbackReviewers = []string{replacement.ReviewerID}
+	}
+
+	return res, replacement.Version, nil
+}
+
+func (prp *PRPostgres) ListPRs(ctx context.Context, filter pr.ListFilter,
+	p page.Request) ([]pr.PRWithReviewersModel, page.Info, error) {
+	after, err := p.After()
+	if err != nil {
+		return nil, page.Info{}, err
+	}
+
+	where := []string{"TRUE"}
+	args := []any{}
+
+	add := func(cond string, val any) {
+		args = append(args, val)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.TeamName != "" {
+		add("a.team_name = $%d", filter.TeamName)
+	}
+
+	if filter.AuthorID != "" {
+		add("pr.author_id = $%d", filter.AuthorID)
+	}
+
+	if filter.ReviewerID != "" {
+		add(`EXISTS (
+			SELECT 1 FROM users_pull_requests AS upr
+			WHERE upr.pull_requests_id = pr.id AND upr.users_id = $%d)`, filter.ReviewerID)
+	}
+
+	if filter.Status != "" {
+		add("pr.status = $%d", filter.Status)
+	}
+
+	if filter.CreatedAfter != nil {
+		add("pr.created_at >= $%d", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		add("pr.created_at < $%d", *filter.CreatedBefore)
+	}
+
+	// served by the trigram index on pull_requests.name
+	if filter.NameQuery != "" {
+		add(`pr.name ILIKE '%%' || $%d || '%%'`, escapeLike(filter.NameQuery))
+	}
+
+	if after != nil {
+		cond, condArgs := after.Where("pr.created_at", "pr.id", len(args)+1)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	args = append(args, p.Fetch())
+
+	sql := fmt.Sprintf(`
+	SELECT pr.id, pr.name, pr.author_id, pr.status, pr.created_at, pr.merged_at, pr.version, `+prLabels+`
+	FROM pull_requests AS pr
+		JOIN users AS a
+			ON a.id = pr.author_id
+	WHERE %s
+	%s
+	LIMIT $%d`, strings.Join(where, " AND "), p.OrderBy("pr.created_at", "pr.id"), len(args))
+
+	rows, err := prp.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, page.Info{}, err
+	}
+
+	defer rows.Close()
+
+	var prs []pr.PRWithReviewersModel
+
+	for rows.Next() {
+		var body pr.PRWithReviewersModel
+		if err := rows.Scan(&body.ID, &body.Name, &body.AuthorID, &body.Status,
+			&body.CreatedAt, &body.MergedAt, &body.Version, &body.Labels); err != nil {
+			return nil, page.Info{}, err
+		}
+
+		prs = append(prs, body)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, page.Info{}, err
+	}
+
+	prs, info := page.Slice(p, prs, func(m pr.PRWithReviewersModel) (time.Time, string) {
+		return *m.CreatedAt, m.ID
+	})
+
+	if err := prp.fillReviewers(ctx, prs); err != nil {
+		return nil, page.Info{}, err
+	}
+
+	return prs, info, nil
+}
+
+// fillReviewers loads the reviewers of a whole page in one query.
+func (prp *PRPostgres) fillReviewers(ctx context.Context, prs []pr.PRWithReviewersModel) error {
+	if len(prs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(prs))
+	idx := make(map[string]int, len(prs))
+
+	for i, p := range prs {
+		ids[i] = p.ID
+		idx[p.ID] = i
+	}
+
+	rows, err := prp.conn.Query(ctx, `
+		SELECT pull_requests_id, users_id
+		FROM users_pull_requests
+		WHERE pull_requests_id = ANY($1)
+		ORDER BY pull_requests_id, users_id`, ids)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return pr.PRModel{}, nil, "", err
+		var prID, userID string
+		if err := rows.Scan(&prID, &userID); err != nil {
+			return err
 		}
 
-		newReviewers = append(newReviewers, id)
+		i := idx[prID]
+		prs[i].Reviewers = append(prs[i].Reviewers, userID)
+	}
+
+	return rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// FillOpenSlots assigns reviewers to open PRs that were created (or left)
+// with fewer reviewers than required. With teamName set only PRs that
+// could draw from that team are tried. PRs still short of candidates stay
+// in the queue.
+func (prp *PRPostgres) FillOpenSlots(ctx context.Context, teamName string) ([]pr.SlotFill, error) {
+	var (
+		fills []pr.SlotFill
+		after string
+	)
+
+	for {
+		fill, prID, err := prp.fillNext(ctx, teamName, after)
+		if err != nil {
+			return fills, err
+		}
+
+		if prID == "" {
+			return fills, nil
+		}
+
+		after = prID
+
+		if len(fill.Reviewers) > 0 {
+			fills = append(fills, fill)
+		}
+	}
+}
+
+// fillNext fills the slots of the first queued PR after the given id in
+// its own transaction. PRs locked by a concurrent request are skipped. It
+// returns the id of the PR it looked at, or "" when the queue is done.
+func (prp *PRPostgres) fillNext(ctx context.Context, teamName, after string) (pr.SlotFill, string, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return pr.SlotFill{}, "", err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var (
+		prm        pr.PRModel
+		authorTeam string
+		slots      int
+	)
+
+	err = tx.QueryRow(ctx, `
+		SELECT pr.id, pr.author_id, COALESCE(u.team_name, ''), pr.unfilled_slots, `+prLabels+`
+		FROM pull_requests AS pr
+			JOIN users AS u
+				ON u.id = pr.author_id
+		WHERE pr.status = 'OPEN'
+		  AND pr.unfilled_slots > 0
+		  AND pr.id > $1
+		  AND ($2 = '' OR u.team_name = $2 OR EXISTS (
+		      SELECT 1 FROM team_fallbacks AS f WHERE f.team_name = u.team_name AND f.fallback_team = $2
+		  ))
+		ORDER BY pr.id
+		LIMIT 1
+		FOR UPDATE OF pr SKIP LOCKED`,
+		after, teamName).Scan(&prm.ID, &prm.AuthorID, &authorTeam, &slots, &prm.Labels)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pr.SlotFill{}, "", nil
+		}
+
+		return pr.SlotFill{}, "", err
+	}
+
+	rule, err := assign.TeamRoleRule(ctx, tx, authorTeam)
+	if err != nil {
+		return pr.SlotFill{}, "", err
+	}
+
+	// the reviewers already on the PR count toward the rule
+	if rule.Min > 0 {
+		have, err := assign.CountWithRole(ctx, tx, prm.ID, rule.Roles)
+		if err != nil {
+			return pr.SlotFill{}, "", err
+		}
+
+		rule.Min -= have
+	}
+
+	ranked, _, err := assign.PickByRole(ctx, tx, authorTeam, prm, rule, nil, slots, prp.policy)
+	if err != nil {
+		// filling the slots with anyone else could leave the rule unmet
+		// for good, so the PR stays queued until a rule role frees up
+		if errors.Is(err, apperrors.ErrRoleRuleUnsatisfied) {
+			return pr.SlotFill{}, prm.ID, nil
+		}
+
+		return pr.SlotFill{}, "", err
+	}
+
+	picked, _, err := assign.PickNewReviewers(ctx, tx, authorTeam, prm, slots-len(ranked),
+		assign.CandidateIDs(ranked), prp.policy)
+	if err != nil && !errors.Is(err, apperrors.ErrNoCapacity) {
+		return pr.SlotFill{}, "", err
+	}
+
+	picked = slices.Concat(ranked, picked)
+
+	if len(picked) == 0 {
+		return pr.SlotFill{}, prm.ID, nil
+	}
+
+	fill := pr.SlotFill{PRID: prm.ID}
+
+	fill.Reviewers, _, err = assign.AssignReviewers(ctx, tx, prm.ID, picked)
+	if err != nil {
+		return pr.SlotFill{}, "", err
+	}
+
+	if err := tx.QueryRow(ctx, `
+		UPDATE pull_requests
+		SET unfilled_slots = unfilled_slots - $2, version = version + 1
+		WHERE id = $1
+		RETURNING unfilled_slots`,
+		prm.ID, len(picked)).Scan(&fill.UnfilledSlots); err != nil {
+		return pr.SlotFill{}, "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pr.SlotFill{}, "", err
+	}
+
+	return fill, prm.ID, nil
+}
+
+// staleReviews joins open reviews without a verdict to the SLA settings of
+// the PR author's team.
+const staleReviews = `
+	FROM users_pull_requests AS upr
+		JOIN pull_requests AS p
+			ON p.id = upr.pull_requests_id
+		JOIN users AS a
+			ON a.id = p.author_id
+		JOIN teams AS t
+			ON t.name = a.team_name
+	WHERE p.status = 'OPEN'
+	  AND upr.state IN ('PENDING', 'ACCEPTED')`
+
+func (prp *PRPostgres) ProcessStaleReviews(ctx context.Context) ([]pr.SLAEvent, error) {
+	// marking and selecting in one statement keeps a reminder from going
+	// out twice, even if two runs overlap
+	rows, err := prp.conn.Query(ctx, `
+		UPDATE users_pull_requests AS r
+		SET reminded_at = NOW()
+		FROM (
+			SELECT upr.pull_requests_id, upr.users_id
+			`+staleReviews+`
+			  AND upr.reminded_at IS NULL
+			  AND t.remind_after_hours > 0
+			  AND upr.assigned_at <= NOW() - make_interval(hours => t.remind_after_hours)
+		) AS s
+		WHERE r.pull_requests_id = s.pull_requests_id AND r.users_id = s.users_id
+		RETURNING r.pull_requests_id, r.users_id, r.assigned_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []pr.SLAEvent
+
+	for rows.Next() {
+		e := pr.SLAEvent{Action: pr.SLAReminder}
+		if err := rows.Scan(&e.PRID, &e.ReviewerID, &e.AssignedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var afterPR, afterUser string
+
+	for {
+		e, err := prp.escalateNext(ctx, afterPR, afterUser)
+		if err != nil {
+			return events, err
+		}
+
+		if e.PRID == "" {
+			return events, nil
+		}
+
+		afterPR, afterUser = e.PRID, e.ReviewerID
+		events = append(events, e)
+	}
+}
+
+func (prp *PRPostgres) ClearReminder(ctx context.Context, prID, reviewerID string) error {
+	_, err := prp.conn.Exec(ctx, `
+		UPDATE users_pull_requests
+		SET reminded_at = NULL
+		WHERE pull_requests_id = $1 AND users_id = $2`,
+		prID, reviewerID)
+
+	return err
+}
+
+// escalateNext handles the first review past its escalation threshold
+// after the given (PR, reviewer) in its own transaction. Reviews of PRs
+// locked by a concurrent request are skipped. It returns a zero SLAEvent
+// when there is nothing left.
+func (prp *PRPostgres) escalateNext(ctx context.Context, afterPR, afterUser string) (pr.SLAEvent, error) {
+	tx, err := prp.conn.Begin(ctx)
+	if err != nil {
+		return pr.SLAEvent{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var (
+		prm    pr.PRModel
+		e      pr.SLAEvent
+		action string
+	)
+
+	err = tx.QueryRow(ctx, `
+		SELECT p.id, p.name, p.author_id, p.status, p.version,
+			upr.users_id, upr.assigned_at, t.escalation_action, COALESCE(t.lead_id, '')
+		`+staleReviews+`
+		  AND upr.escalated_at IS NULL
+		  AND t.escalate_after_hours > 0
+		  AND upr.assigned_at <= NOW() - make_interval(hours => t.escalate_after_hours)
+		  AND (upr.pull_requests_id, upr.users_id) > ($1, $2)
+		ORDER BY upr.pull_requests_id, upr.users_id
+		LIMIT 1
+		FOR UPDATE OF p SKIP LOCKED`,
+		afterPR, afterUser).Scan(&prm.ID, &prm.Name, &prm.AuthorID, &prm.Status, &prm.Version,
+		&e.ReviewerID, &e.AssignedAt, &action, &e.LeadID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pr.SLAEvent{}, nil
+		}
+
+		return pr.SLAEvent{}, err
+	}
+
+	e.PRID = prm.ID
+
+	if action == pr.EscalationReassign {
+		replacement, err := assign.ReplaceReviewer(ctx, tx, prm, e.ReviewerID,
+			assign.ReplaceOptions{Reason: pr.ReasonSLAExpired}, prp.policy)
+
+		switch {
+		case err == nil:
+			e.Action = pr.SLAReassigned
+			e.ReplacedBy = replacement.ReviewerID
+			e.LeadID = ""
+		case assign.Unfillable(err):
+			// nobody can take it over, so the lead has to step in
+		default:
+			return pr.SLAEvent{}, err
+		}
+	}
+
+	if e.Action == "" {
+		e.Action = pr.SLAEscalated
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE users_pull_requests
+			SET escalated_at = NOW()
+			WHERE pull_requests_id = $1 AND users_id = $2`,
+			prm.ID, e.ReviewerID); err != nil {
+			return pr.SLAEvent{}, err
+		}
+
+		if err := assign.AddHistory(ctx, tx, prm.ID, pr.EventReviewEscalated, e.LeadID, e.ReviewerID,
+			pr.ReasonSLAExpired); err != nil {
+			return pr.SLAEvent{}, err
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return pr.PRModel{}, nil, "", err
+		return pr.SLAEvent{}, err
 	}
 
-	return bodyPr, newReviewers, newReviewerID, nil
+	return e, nil
 }
 
-func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger) *PRPostgres {
+func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger, policy pr.AssignmentPolicy) *PRPostgres {
 	return &PRPostgres{
-		conn: conn,
-		log:  log,
+		conn:   conn,
+		log:    log,
+		policy: policy,
 	}
 }
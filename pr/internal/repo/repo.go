@@ -1,21 +1,35 @@
 package repo
 
 import (
+	"pr/internal/repo/idempotency"
+	"pr/internal/repo/lock"
 	"pr/internal/repo/pr"
+	"pr/internal/repo/tag"
 	"pr/internal/repo/team"
+	"pr/internal/repo/unavailability"
 	"pr/internal/repo/user"
 )
 
 type Repo struct {
-	Team team.UserInterface
-	User user.UserInterface
-	PR   pr.PRInterface
+	Team           team.UserInterface
+	User           user.UserInterface
+	PR             pr.PRInterface
+	Idempotency    idempotency.IdempotencyInterface
+	Unavailability unavailability.UnavailabilityInterface
+	Lock           lock.LockInterface
+	Tag            tag.TagInterface
 }
 
-func NewRepo(teamDB team.UserInterface, userDB user.UserInterface, prDB pr.PRInterface) *Repo {
+func NewRepo(teamDB team.UserInterface, userDB user.UserInterface, prDB pr.PRInterface,
+	idempotencyDB idempotency.IdempotencyInterface, unavailabilityDB unavailability.UnavailabilityInterface,
+	lockDB lock.LockInterface, tagDB tag.TagInterface) *Repo {
 	return &Repo{
-		Team: teamDB,
-		User: userDB,
-		PR:   prDB,
+		Team:           teamDB,
+		User:           userDB,
+		PR:             prDB,
+		Idempotency:    idempotencyDB,
+		Unavailability: unavailabilityDB,
+		Lock:           lockDB,
+		Tag:            tagDB,
 	}
 }
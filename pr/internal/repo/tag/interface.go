@@ -0,0 +1,14 @@
+package tag
+
+import "context"
+
+type TagInterface interface {
+	CreateTag(ctx context.Context, t TagModel) (TagModel, error)
+	GetTag(ctx context.Context, name string) (TagModel, error)
+	ListTags(ctx context.Context) ([]TagModel, error)
+	// UpdateTag replaces the description and match mode of the tag.
+	UpdateTag(ctx context.Context, t TagModel) (TagModel, error)
+	// DeleteTag removes the tag from the catalog, from every user's skills
+	// and from every PR's labels.
+	DeleteTag(ctx context.Context, name string) error
+}
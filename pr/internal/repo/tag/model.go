@@ -0,0 +1,20 @@
+package tag
+
+import "time"
+
+// how a PR label steers reviewer selection
+const (
+	// reviewers with the skill are ranked before the others
+	ModePrefer = "prefer"
+	// at least one reviewer must have the skill
+	ModeRequire = "require"
+)
+
+// TagModel is a tag of the catalog, used both as a user skill and as a
+// PR label.
+type TagModel struct {
+	CreatedAt   time.Time
+	Name        string
+	Description string
+	Mode        string
+}
@@ -0,0 +1,118 @@
+package tagpostgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/repo/tag"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TagPostgres struct {
+	conn *pgxpool.Pool
+	log  *slog.Logger
+}
+
+const tagColumns = `name, COALESCE(description, ''), match_mode, created_at`
+
+func scanTag(row pgx.Row) (tag.TagModel, error) {
+	var t tag.TagModel
+
+	err := row.Scan(&t.Name, &t.Description, &t.Mode, &t.CreatedAt)
+
+	return t, err
+}
+
+func (tp *TagPostgres) CreateTag(ctx context.Context, t tag.TagModel) (tag.TagModel, error) {
+	res, err := scanTag(tp.conn.QueryRow(ctx, `
+		INSERT INTO tags (name, description, match_mode)
+		VALUES ($1, NULLIF($2, ''), $3)
+		RETURNING `+tagColumns,
+		t.Name, t.Description, t.Mode))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return tag.TagModel{}, apperrors.ErrTagExists
+		}
+
+		return tag.TagModel{}, err
+	}
+
+	return res, nil
+}
+
+func (tp *TagPostgres) GetTag(ctx context.Context, name string) (tag.TagModel, error) {
+	res, err := scanTag(tp.conn.QueryRow(ctx, "SELECT "+tagColumns+" FROM tags WHERE name = $1", name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return tag.TagModel{}, apperrors.ErrNotFound
+		}
+
+		return tag.TagModel{}, err
+	}
+
+	return res, nil
+}
+
+func (tp *TagPostgres) ListTags(ctx context.Context) ([]tag.TagModel, error) {
+	rows, err := tp.conn.Query(ctx, "SELECT "+tagColumns+" FROM tags ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []tag.TagModel
+
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+func (tp *TagPostgres) UpdateTag(ctx context.Context, t tag.TagModel) (tag.TagModel, error) {
+	res, err := scanTag(tp.conn.QueryRow(ctx, `
+		UPDATE tags
+		SET description = NULLIF($2, ''), match_mode = $3
+		WHERE name = $1
+		RETURNING `+tagColumns,
+		t.Name, t.Description, t.Mode))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return tag.TagModel{}, apperrors.ErrNotFound
+		}
+
+		return tag.TagModel{}, err
+	}
+
+	return res, nil
+}
+
+func (tp *TagPostgres) DeleteTag(ctx context.Context, name string) error {
+	res, err := tp.conn.Exec(ctx, "DELETE FROM tags WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+
+	return nil
+}
+
+func NewTagPostgres(conn *pgxpool.Pool, log *slog.Logger) *TagPostgres {
+	return &TagPostgres{
+		conn: conn,
+		log:  log,
+	}
+}
@@ -2,10 +2,32 @@ package team
 
 import (
 	"context"
+	"pr/internal/repo/page"
 	"pr/internal/repo/user"
 )
 
 type UserInterface interface {
-	CreateOrUpdateTeamWithUsers(ctx context.Context, team string, users []user.UserModel) (string, []user.UserModel, error)
-	GetUsersFromTeam(ctx context.Context, team string) ([]user.UserModel, error)
+	// CreateOrUpdateTeamWithUsers creates the team when expectedVersion is nil,
+	// otherwise updates the existing team if its version matches.
+	CreateOrUpdateTeamWithUsers(ctx context.Context, team string, users []user.UserModel,
+		expectedVersion *int64) (TeamModel, []user.UserModel, error)
+	GetUsersFromTeam(ctx context.Context, team string, filter MemberFilter,
+		p page.Request) (TeamModel, []user.UserModel, page.Info, error)
+	// DeleteTeam removes the team and detaches its members. It fails while
+	// members author open PRs.
+	DeleteTeam(ctx context.Context, team string, expectedVersion *int64) error
+	// RemoveMembers detaches the users from the team and hands their open
+	// reviews over to other members. It fails while any of them authors
+	// open PRs.
+	RemoveMembers(ctx context.Context, team string, userIDs []string,
+		expectedVersion *int64) (TeamModel, []user.ReassignedReview, error)
+	RenameTeam(ctx context.Context, team, newName string, expectedVersion *int64) (TeamModel, error)
+	GetSettings(ctx context.Context, team string) (TeamModel, Settings, error)
+	// SetSettings replaces the team settings. Fallback teams must exist.
+	SetSettings(ctx context.Context, team string, settings Settings, expectedVersion *int64) (TeamModel, error)
+	// GetCodeOwners returns the team's code owner rules in match order.
+	GetCodeOwners(ctx context.Context, team string) (TeamModel, []CodeOwnerRule, error)
+	// SetCodeOwners replaces the team's code owner rules. Owners must be
+	// existing users.
+	SetCodeOwners(ctx context.Context, team string, rules []CodeOwnerRule, expectedVersion *int64) (TeamModel, error)
 }
@@ -1 +1,57 @@
 package team
+
+type TeamModel struct {
+	Name    string
+	Version int64
+}
+
+// Settings control how reviewers are picked for PRs of the team's members.
+type Settings struct {
+	// FallbackTeams supply reviewers, in this order, when the team itself
+	// has too few candidates.
+	FallbackTeams []string
+	// ReviewerPolicy is pr.PolicyAllowPartial or pr.PolicyRequireFull.
+	ReviewerPolicy string
+	MergePolicy    MergePolicy
+	ReviewSLA      ReviewSLA
+	RoleRule       RoleRule
+}
+
+// MergePolicy is what PRs of the team's members need before a merge.
+// The zero value lets every PR merge.
+type MergePolicy struct {
+	MinApprovals            int
+	BlockOnChangesRequested bool
+}
+
+// RoleRule asks for at least MinReviewers reviewers whose role is MinRole
+// or above on every PR of the team's members. Zero MinReviewers turns the
+// rule off.
+type RoleRule struct {
+	MinReviewers int
+	// MinRole is one of user.Roles.
+	MinRole string
+}
+
+// ReviewSLA sets how long a review may wait for a verdict. Zero hours
+// turn a threshold off.
+type ReviewSLA struct {
+	RemindAfterHours   int
+	EscalateAfterHours int
+	// EscalationAction is pr.EscalationReassign or pr.EscalationEscalate.
+	EscalationAction string
+	// LeadID is notified of escalated reviews; empty if the team has no lead.
+	LeadID string
+}
+
+// CodeOwnerRule gives the files matching Pattern to Owners, as a line of
+// a CODEOWNERS file does. Later rules win over earlier ones.
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// MemberFilter narrows the team members list. Nil fields match everything.
+type MemberFilter struct {
+	IsActive *bool
+}
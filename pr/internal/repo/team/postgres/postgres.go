@@ -6,127 +6,907 @@ import (
 	"fmt"
 	"log/slog"
 	apperrors "pr/internal/app/errors"
+	"pr/internal/repo"
+	"pr/internal/repo/assign"
+	"pr/internal/repo/page"
+	"pr/internal/repo/pr"
 	"pr/internal/repo/team"
 	"pr/internal/repo/user"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
 type TeamPostgres struct {
-	conn *pgxpool.Pool
-	log  *slog.Logger
+	conn   *pgxpool.Pool
+	log    *slog.Logger
+	policy pr.AssignmentPolicy
 }
 
-func (u *TeamPostgres) CreateOrUpdateTeamWithUsers(ctx context.Context, team string,
-	users []user.UserModel) (string, []user.UserModel, error) {
+func (u *TeamPostgres) CreateOrUpdateTeamWithUsers(ctx context.Context, teamName string,
+	users []user.UserModel, expectedVersion *int64) (team.TeamModel, []user.UserModel, error) {
 	tx, err := u.conn.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.Serializable,
 	})
 
 	if err != nil {
-		return "", nil, err
+		return team.TeamModel{}, nil, err
 	}
 
 	defer func() {
 		_ = tx.Rollback(ctx)
 	}()
 
-	sqlTeam := `
-	INSERT INTO teams (name)
-	VALUES ($1)
-	;
-	`
+	res := team.TeamModel{Name: teamName}
 
-	if _, err := tx.Exec(ctx, sqlTeam, team); err != nil {
-		var pgErr *pgconn.PgError
+	if expectedVersion == nil {
+		sqlTeam := `
+		INSERT INTO teams (name)
+		VALUES ($1)
+		RETURNING version
+		;
+		`
 
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return "", nil, apperrors.ErrTeamExists
-		} else {
-			return "", nil, err
+		if err := tx.QueryRow(ctx, sqlTeam, teamName).Scan(&res.Version); err != nil {
+			var pgErr *pgconn.PgError
+
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return team.TeamModel{}, nil, apperrors.ErrTeamExists
+			} else {
+				return team.TeamModel{}, nil, err
+			}
+		}
+	} else {
+		res.Version, err = bumpTeamVersion(ctx, tx, teamName, expectedVersion)
+		if err != nil {
+			return team.TeamModel{}, nil, err
 		}
 	}
 
 	if len(users) > 0 {
+		ids := make([]string, 0, len(users))
+		for _, val := range users {
+			ids = append(ids, val.ID)
+		}
+
+		if err := checkNotDeleted(ctx, tx, ids); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		// members moved here from other teams change those teams too
+		if _, err := tx.Exec(ctx, `
+			UPDATE teams SET version = version + 1
+			WHERE name IN (SELECT team_name FROM users WHERE id = ANY($1) AND team_name != $2)`,
+			ids, teamName); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
 		arrSQLUser := make([]string, 0, len(users))
-		args := make([]interface{}, 0, len(users)*4)
+		args := make([]interface{}, 0, len(users)*6)
 
 		arrSQLUser = append(arrSQLUser,
-			`INSERT INTO users (id, username, team_name, is_active) VALUES`)
+			`INSERT INTO users (id, username, team_name, is_active, max_open_reviews, role) VALUES`)
 
 		for ind, val := range users {
 			var str string
 			if ind == len(users)-1 {
-				str = "($%d, $%d, $%d, $%d)"
+				str = "($%d, $%d, $%d, $%d, $%d, NULLIF($%d, ''))"
 			} else {
-				str = "($%d, $%d, $%d, $%d),"
+				str = "($%d, $%d, $%d, $%d, $%d, NULLIF($%d, '')),"
 			}
 
-			arrSQLUser = append(arrSQLUser, fmt.Sprintf(str, ind*4+1, ind*4+2, ind*4+3, ind*4+4))
-			args = append(args, val.ID, val.UserName, team, val.IsActive)
+			arrSQLUser = append(arrSQLUser, fmt.Sprintf(str, ind*6+1, ind*6+2, ind*6+3, ind*6+4, ind*6+5, ind*6+6))
+			args = append(args, val.ID, val.UserName, teamName, val.IsActive, val.MaxOpenReviews, val.Role)
 		}
 
+		// a member sent without max_open_reviews or role keeps the current one
 		arrSQLUser = append(arrSQLUser, `
 		ON CONFLICT (id)
 		DO UPDATE SET
 			username = EXCLUDED.username,
 			team_name = EXCLUDED.team_name,
-			is_active = EXCLUDED.is_active;`)
+			is_active = EXCLUDED.is_active,
+			max_open_reviews = COALESCE(EXCLUDED.max_open_reviews, users.max_open_reviews),
+			role = COALESCE(EXCLUDED.role, users.role)
+		RETURNING id, COALESCE(role, '');`)
 
-		if _, err := tx.Exec(ctx, strings.Join(arrSQLUser, "\n"), args...); err != nil {
-			return "", nil, err
+		rows, err := tx.Query(ctx, strings.Join(arrSQLUser, "\n"), args...)
+		if err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		roles := make(map[string]string, len(users))
+
+		for rows.Next() {
+			var id, role string
+			if err := rows.Scan(&id, &role); err != nil {
+				rows.Close()
+				return team.TeamModel{}, nil, err
+			}
+
+			roles[id] = role
+		}
+
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		for i := range users {
+			users[i].Role = roles[users[i].ID]
+		}
+
+		if err := setSkills(ctx, tx, users); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		if err := fillSkills(ctx, tx, users); err != nil {
+			return team.TeamModel{}, nil, err
 		}
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		return "", nil, err
+		return team.TeamModel{}, nil, err
+	}
+
+	return res, users, nil
+}
+
+func (u *TeamPostgres) DeleteTeam(ctx context.Context, teamName string, expectedVersion *int64) error {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := lockTeam(ctx, tx, teamName, expectedVersion); err != nil {
+		return err
+	}
+
+	// PRs of a deleted team could never get new reviewers, so they have to
+	// be merged (or their authors moved) first
+	var hasOpenPRs bool
+
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM pull_requests AS pr
+				JOIN users AS u
+					ON u.id = pr.author_id
+			WHERE u.team_name = $1 AND pr.status = 'OPEN'
+		)`, teamName).Scan(&hasOpenPRs); err != nil {
+		return err
+	}
+
+	if hasOpenPRs {
+		return apperrors.ErrTeamHasOpenPRs
+	}
+
+	// members, their PRs and review history stay; users.team_name is set
+	// to NULL by the foreign key
+	if _, err := tx.Exec(ctx, "DELETE FROM teams WHERE name = $1", teamName); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (u *TeamPostgres) RemoveMembers(ctx context.Context, teamName string, userIDs []string,
+	expectedVersion *int64) (team.TeamModel, []user.ReassignedReview, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	res := team.TeamModel{Name: teamName}
+
+	res.Version, err = bumpTeamVersion(ctx, tx, teamName, expectedVersion)
+	if err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	var memberCount int
+
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM (
+			SELECT id
+			FROM users
+			WHERE team_name = $1 AND id = ANY($2)
+			FOR UPDATE
+		) AS members`,
+		teamName, userIDs).Scan(&memberCount); err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	// every listed user has to be a member, otherwise nothing is removed
+	if memberCount != len(userIDs) {
+		return team.TeamModel{}, nil, apperrors.ErrNotFound
+	}
+
+	// like a deleted team, a removed member's PRs could never get new
+	// reviewers
+	var hasOpenPRs bool
+
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM pull_requests
+			WHERE author_id = ANY($1) AND status = 'OPEN'
+		)`, userIDs).Scan(&hasOpenPRs); err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	if hasOpenPRs {
+		return team.TeamModel{}, nil, apperrors.ErrMembersHaveOpenPRs
+	}
+
+	// detach everyone first so no removed member is picked as another
+	// one's replacement
+	if _, err := tx.Exec(ctx,
+		"UPDATE users SET team_name = NULL WHERE id = ANY($1)", userIDs); err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	var reassigned []user.ReassignedReview
+
+	for _, userID := range userIDs {
+		open, err := assign.LockOpenReviews(ctx, tx, userID)
+		if err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		handed, err := assign.HandOverReviews(ctx, tx, open, userID, pr.ReasonTeamRemoved, u.policy)
+		if err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		reassigned = append(reassigned, handed...)
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_history (user_id, event, from_team)
+			VALUES ($1, $2, $3)`,
+			userID, user.EventTeamRemoved, teamName); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	return res, reassigned, nil
+}
+
+func (u *TeamPostgres) RenameTeam(ctx context.Context, teamName, newName string,
+	expectedVersion *int64) (team.TeamModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return team.TeamModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := lockTeam(ctx, tx, teamName, expectedVersion); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	res := team.TeamModel{Name: newName}
+
+	// users.team_name follows through ON UPDATE CASCADE
+	err = tx.QueryRow(ctx, `
+		UPDATE teams SET name = $2, version = version + 1
+		WHERE name = $1
+		RETURNING version`,
+		teamName, newName).Scan(&res.Version)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return team.TeamModel{}, apperrors.ErrTeamExists
+		}
+
+		return team.TeamModel{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	return res, nil
+}
+
+func (u *TeamPostgres) GetSettings(ctx context.Context, teamName string) (team.TeamModel, team.Settings, error) {
+	res := team.TeamModel{Name: teamName}
+	settings := team.Settings{FallbackTeams: []string{}}
+
+	err := u.conn.QueryRow(ctx, `
+		SELECT version, reviewer_policy, min_approvals, block_on_changes_requested,
+			remind_after_hours, escalate_after_hours, escalation_action, COALESCE(lead_id, ''),
+			min_role_reviewers, min_reviewer_role
+		FROM teams
+		WHERE name = $1`,
+		teamName).Scan(&res.Version, &settings.ReviewerPolicy,
+		&settings.MergePolicy.MinApprovals, &settings.MergePolicy.BlockOnChangesRequested,
+		&settings.ReviewSLA.RemindAfterHours, &settings.ReviewSLA.EscalateAfterHours,
+		&settings.ReviewSLA.EscalationAction, &settings.ReviewSLA.LeadID,
+		&settings.RoleRule.MinReviewers, &settings.RoleRule.MinRole)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return team.TeamModel{}, team.Settings{}, apperrors.ErrNotFound
+		}
+
+		return team.TeamModel{}, team.Settings{}, err
+	}
+
+	rows, err := u.conn.Query(ctx, `
+		SELECT fallback_team FROM team_fallbacks WHERE team_name = $1 ORDER BY position`, teamName)
+	if err != nil {
+		return team.TeamModel{}, team.Settings{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return team.TeamModel{}, team.Settings{}, err
+		}
+
+		settings.FallbackTeams = append(settings.FallbackTeams, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return team.TeamModel{}, team.Settings{}, err
+	}
+
+	return res, settings, nil
+}
+
+func (u *TeamPostgres) SetSettings(ctx context.Context, teamName string, settings team.Settings,
+	expectedVersion *int64) (team.TeamModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return team.TeamModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	res := team.TeamModel{Name: teamName}
+
+	res.Version, err = bumpTeamVersion(ctx, tx, teamName, expectedVersion)
+	if err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if err := checkTeamsExist(ctx, tx, "fallback_teams", settings.FallbackTeams); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if err := checkLeadExists(ctx, tx, settings.ReviewSLA.LeadID); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	sla := settings.ReviewSLA
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE teams
+		SET reviewer_policy = $2, min_approvals = $3, block_on_changes_requested = $4,
+			remind_after_hours = $5, escalate_after_hours = $6, escalation_action = $7,
+			lead_id = NULLIF($8, ''), min_role_reviewers = $9, min_reviewer_role = $10
+		WHERE name = $1`,
+		teamName, settings.ReviewerPolicy,
+		settings.MergePolicy.MinApprovals, settings.MergePolicy.BlockOnChangesRequested,
+		sla.RemindAfterHours, sla.EscalateAfterHours, sla.EscalationAction, sla.LeadID,
+		settings.RoleRule.MinReviewers, settings.RoleRule.MinRole); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM team_fallbacks WHERE team_name = $1", teamName); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO team_fallbacks (team_name, fallback_team, position)
+		SELECT $1, f.name, f.position
+		FROM unnest($2::text[]) WITH ORDINALITY AS f(name, position)`,
+		teamName, settings.FallbackTeams); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	return res, nil
+}
+
+func (u *TeamPostgres) GetCodeOwners(ctx context.Context, teamName string) (team.TeamModel, []team.CodeOwnerRule,
+	error) {
+	res := team.TeamModel{Name: teamName}
+
+	err := u.conn.QueryRow(ctx, "SELECT version FROM teams WHERE name = $1", teamName).Scan(&res.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return team.TeamModel{}, nil, apperrors.ErrNotFound
+		}
+
+		return team.TeamModel{}, nil, err
+	}
+
+	rows, err := u.conn.Query(ctx, `
+		SELECT pattern, owners FROM team_code_owners WHERE team_name = $1 ORDER BY position`, teamName)
+	if err != nil {
+		return team.TeamModel{}, nil, err
+	}
+	defer rows.Close()
+
+	rules := []team.CodeOwnerRule{}
+
+	for rows.Next() {
+		var rule team.CodeOwnerRule
+		if err := rows.Scan(&rule.Pattern, &rule.Owners); err != nil {
+			return team.TeamModel{}, nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return team.TeamModel{}, nil, err
+	}
+
+	return res, rules, nil
+}
+
+func (u *TeamPostgres) SetCodeOwners(ctx context.Context, teamName string, rules []team.CodeOwnerRule,
+	expectedVersion *int64) (team.TeamModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return team.TeamModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	res := team.TeamModel{Name: teamName}
+
+	res.Version, err = bumpTeamVersion(ctx, tx, teamName, expectedVersion)
+	if err != nil {
+		return team.TeamModel{}, err
 	}
 
-	return team, users, nil
+	if err := checkOwnersExist(ctx, tx, rules); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM team_code_owners WHERE team_name = $1", teamName); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	for i, rule := range rules {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO team_code_owners (team_name, position, pattern, owners)
+			VALUES ($1, $2, $3, $4)`,
+			teamName, i+1, rule.Pattern, rule.Owners); err != nil {
+			return team.TeamModel{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return team.TeamModel{}, err
+	}
+
+	return res, nil
 }
 
-func (u *TeamPostgres) GetUsersFromTeam(ctx context.Context, team string) ([]user.UserModel, error) {
+// checkOwnersExist fails with a validation error on rules[i].owners[j]
+// for every owner that is not an existing user.
+func checkOwnersExist(ctx context.Context, tx pgx.Tx, rules []team.CodeOwnerRule) error {
+	var ids []string
+	for _, rule := range rules {
+		ids = append(ids, rule.Owners...)
+	}
+
+	rows, err := tx.Query(ctx, "SELECT id FROM users WHERE id = ANY($1) AND deleted_at IS NULL", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(ids))
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		existing[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var details []apperrors.FieldError
+
+	for i, rule := range rules {
+		for j, id := range rule.Owners {
+			if !existing[id] {
+				details = append(details, apperrors.FieldError{
+					Field:   fmt.Sprintf("rules[%d].owners[%d]", i, j),
+					Code:    "NOT_FOUND",
+					Message: "user does not exist",
+				})
+			}
+		}
+	}
+
+	if len(details) > 0 {
+		return apperrors.NewValidationError(details)
+	}
+
+	return nil
+}
+
+// checkNotDeleted rejects soft-deleted users, whom the upsert would
+// otherwise bring back half restored. ids are in members order.
+func checkNotDeleted(ctx context.Context, tx pgx.Tx, ids []string) error {
+	rows, err := tx.Query(ctx, "SELECT id FROM users WHERE id = ANY($1) AND deleted_at IS NOT NULL", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	deleted := make(map[string]bool)
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		deleted[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var details []apperrors.FieldError
+
+	for i, id := range ids {
+		if deleted[id] {
+			details = append(details, apperrors.FieldError{
+				Field:   fmt.Sprintf("members[%d].user_id", i),
+				Code:    "DELETED",
+				Message: "user is deleted; restore it with POST /api/v1/users/{id}/restore first",
+			})
+		}
+	}
+
+	if len(details) > 0 {
+		return apperrors.NewValidationError(details)
+	}
+
+	return nil
+}
+
+// checkLeadExists fails with a validation error if a lead is set and is
+// not an existing user.
+func checkLeadExists(ctx context.Context, tx pgx.Tx, leadID string) error {
+	if leadID == "" {
+		return nil
+	}
+
 	var exists bool
 
-	if err := u.conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE name = $1)", team).Scan(&exists); err != nil {
-		return nil, err
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)",
+		leadID).Scan(&exists); err != nil {
+		return err
 	}
 
 	if !exists {
-		return nil, apperrors.ErrNotFound
+		return apperrors.NewValidationError([]apperrors.FieldError{{
+			Field:   "review_sla.lead_id",
+			Code:    "NOT_FOUND",
+			Message: "user does not exist",
+		}})
 	}
 
-	var res []user.UserModel
+	return nil
+}
 
-	rows, err := u.conn.Query(ctx,
-		`SELECT id, username, team_name, is_active 
-		FROM teams 
-			LEFT JOIN users 
-				ON team_name = name WHERE team_name = $1
-		`, team)
+// checkTeamsExist fails with a validation error on field[i] for every
+// listed team that does not exist.
+func checkTeamsExist(ctx context.Context, tx pgx.Tx, field string, names []string) error {
+	rows, err := tx.Query(ctx, "SELECT name FROM teams WHERE name = ANY($1)", names)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(names))
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+
+		existing[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var details []apperrors.FieldError
+
+	for i, name := range names {
+		if !existing[name] {
+			details = append(details, apperrors.FieldError{
+				Field:   fmt.Sprintf("%s[%d]", field, i),
+				Code:    "NOT_FOUND",
+				Message: "team does not exist",
+			})
+		}
+	}
+
+	if len(details) > 0 {
+		return apperrors.NewValidationError(details)
+	}
+
+	return nil
+}
+
+// lockTeam locks the team row and checks its version against the expected one.
+func lockTeam(ctx context.Context, tx pgx.Tx, teamName string, expectedVersion *int64) (int64, error) {
+	var current int64
+
+	err := tx.QueryRow(ctx, "SELECT version FROM teams WHERE name = $1 FOR UPDATE", teamName).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, apperrors.ErrNotFound
+		}
+
+		return 0, err
+	}
+
+	if !repo.VersionMatches(expectedVersion, current) {
+		return 0, apperrors.ErrPreconditionFailed
+	}
+
+	return current, nil
+}
+
+// bumpTeamVersion increments the team version if it matches the expected one.
+func bumpTeamVersion(ctx context.Context, tx pgx.Tx, teamName string, expectedVersion *int64) (int64, error) {
+	current, err := lockTeam(ctx, tx, teamName, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.QueryRow(ctx,
+		"UPDATE teams SET version = version + 1 WHERE name = $1 RETURNING version", teamName).Scan(&current); err != nil {
+		return 0, err
+	}
+
+	return current, nil
+}
+
+func (u *TeamPostgres) GetUsersFromTeam(ctx context.Context, teamName string, filter team.MemberFilter,
+	p page.Request) (team.TeamModel, []user.UserModel, page.Info, error) {
+	after, err := p.After()
+	if err != nil {
+		return team.TeamModel{}, nil, page.Info{}, err
+	}
+
+	res := team.TeamModel{Name: teamName}
+
+	err = u.conn.QueryRow(ctx, "SELECT version FROM teams WHERE name = $1", teamName).Scan(&res.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return team.TeamModel{}, nil, page.Info{}, apperrors.ErrNotFound
+		}
+
+		return team.TeamModel{}, nil, page.Info{}, err
+	}
+
+	where := []string{"team_name = $1", "deleted_at IS NULL"}
+	args := []any{teamName}
+
+	if filter.IsActive != nil {
+		args = append(args, *filter.IsActive)
+		where = append(where, fmt.Sprintf("is_active = $%d", len(args)))
+	}
+
+	if after != nil {
+		cond, condArgs := after.Where("created_at", "id", len(args)+1)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	args = append(args, p.Fetch())
+
+	var mems []user.UserModel
+
+	rows, err := u.conn.Query(ctx, fmt.Sprintf(
+		`SELECT id, username, team_name, is_active, created_at, max_open_reviews, COALESCE(role, '')
+		FROM users
+		WHERE %s
+		%s
+		LIMIT $%d
+		`, strings.Join(where, " AND "), p.OrderBy("created_at", "id"), len(args)), args...)
+	if err != nil {
+		return team.TeamModel{}, nil, page.Info{}, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var body user.UserModel
-		if err := rows.Scan(&body.ID, &body.UserName, &body.TeamName, &body.IsActive); err != nil {
+		if err := rows.Scan(&body.ID, &body.UserName, &body.TeamName, &body.IsActive, &body.CreatedAt,
+			&body.MaxOpenReviews, &body.Role); err != nil {
+			return team.TeamModel{}, nil, page.Info{}, err
+		}
+
+		mems = append(mems, body)
+	}
+
+	if err := rows.Err(); err != nil {
+		return team.TeamModel{}, nil, page.Info{}, err
+	}
+
+	mems, info := page.Slice(p, mems, func(m user.UserModel) (time.Time, string) {
+		return m.CreatedAt, m.ID
+	})
+
+	if err := fillSkills(ctx, u.conn, mems); err != nil {
+		return team.TeamModel{}, nil, page.Info{}, err
+	}
+
+	return res, mems, info, nil
+}
+
+// setSkills replaces the skills of the users sent with a non-nil list.
+// Every skill must be a tag of the catalog.
+func setSkills(ctx context.Context, tx pgx.Tx, users []user.UserModel) error {
+	var (
+		ids, names []string
+		details    []apperrors.FieldError
+	)
+
+	for _, m := range users {
+		if m.Skills != nil {
+			ids = append(ids, m.ID)
+			names = append(names, m.Skills...)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	existing, err := existingTags(ctx, tx, names)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range users {
+		for j, name := range m.Skills {
+			if !existing[name] {
+				details = append(details, apperrors.FieldError{
+					Field:   fmt.Sprintf("members[%d].skills[%d]", i, j),
+					Code:    "NOT_FOUND",
+					Message: "tag does not exist",
+				})
+			}
+		}
+	}
+
+	if len(details) > 0 {
+		return apperrors.NewValidationError(details)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM user_skills WHERE user_id = ANY($1)", ids); err != nil {
+		return err
+	}
+
+	for _, m := range users {
+		if len(m.Skills) == 0 {
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_skills (user_id, tag)
+			SELECT $1, unnest($2::text[])`,
+			m.ID, m.Skills); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func existingTags(ctx context.Context, tx pgx.Tx, names []string) (map[string]bool, error) {
+	rows, err := tx.Query(ctx, "SELECT name FROM tags WHERE name = ANY($1)", names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(names))
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
 			return nil, err
 		}
 
-		res = append(res, body)
+		existing[name] = true
 	}
 
-	return res, nil
+	return existing, rows.Err()
+}
+
+// fillSkills loads the skills of the users in one query.
+func fillSkills(ctx context.Context, q querier, users []user.UserModel) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(users))
+	idx := make(map[string]int, len(users))
+
+	for i, m := range users {
+		ids[i] = m.ID
+		idx[m.ID] = i
+		users[i].Skills = []string{}
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT user_id, tag FROM user_skills WHERE user_id = ANY($1) ORDER BY user_id, tag`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, name string
+		if err := rows.Scan(&userID, &name); err != nil {
+			return err
+		}
+
+		i := idx[userID]
+		users[i].Skills = append(users[i].Skills, name)
+	}
+
+	return rows.Err()
 }
 
-func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger) team.UserInterface {
+func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger, policy pr.AssignmentPolicy) team.UserInterface {
 	return &TeamPostgres{
-		conn: conn,
-		log:  log,
+		conn:   conn,
+		log:    log,
+		policy: policy,
 	}
 }
@@ -0,0 +1,15 @@
+package unavailability
+
+import "context"
+
+type UnavailabilityInterface interface {
+	CreateWindow(ctx context.Context, w WindowModel) (WindowModel, error)
+	ListWindows(ctx context.Context, userID string) ([]WindowModel, error)
+	UpdateWindow(ctx context.Context, w WindowModel) (WindowModel, error)
+	DeleteWindow(ctx context.Context, userID string, id int64) error
+	// HandOverStarted reassigns the open reviews of users whose windows have
+	// started and were not handled yet. Reviews nobody can take stay with
+	// the user. A window whose handover fails stays unhandled without
+	// holding back the others; the errors are joined.
+	HandOverStarted(ctx context.Context) ([]HandoverModel, error)
+}
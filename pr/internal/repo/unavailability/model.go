@@ -0,0 +1,25 @@
+package unavailability
+
+import (
+	"pr/internal/repo/user"
+	"time"
+)
+
+// WindowModel is a period during which the user must not get new reviews.
+type WindowModel struct {
+	StartsAt  time.Time
+	EndsAt    time.Time
+	HandledAt *time.Time
+	UserID    string
+	Reason    string
+	ID        int64
+}
+
+// HandoverModel reports a window whose open reviews were handed over.
+// Kept counts the reviews nobody could take.
+type HandoverModel struct {
+	UserID     string
+	Reassigned []user.ReassignedReview
+	WindowID   int64
+	Kept       int
+}
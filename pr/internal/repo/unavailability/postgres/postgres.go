@@ -0,0 +1,234 @@
+package unavailabilitypostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/repo/assign"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/unavailability"
+	"pr/internal/repo/user"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UnavailabilityPostgres struct {
+	conn   *pgxpool.Pool
+	log    *slog.Logger
+	policy pr.AssignmentPolicy
+}
+
+const windowColumns = `id, user_id, starts_at, ends_at, COALESCE(reason, ''), handled_at`
+
+func scanWindow(row pgx.Row) (unavailability.WindowModel, error) {
+	var w unavailability.WindowModel
+
+	err := row.Scan(&w.ID, &w.UserID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.HandledAt)
+
+	return w, err
+}
+
+func (up *UnavailabilityPostgres) CreateWindow(ctx context.Context,
+	w unavailability.WindowModel) (unavailability.WindowModel, error) {
+	res, err := scanWindow(up.conn.QueryRow(ctx, `
+		INSERT INTO user_unavailability (user_id, starts_at, ends_at, reason)
+		SELECT id, $2, $3, NULLIF($4, '')
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+windowColumns,
+		w.UserID, w.StartsAt, w.EndsAt, w.Reason))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return unavailability.WindowModel{}, apperrors.ErrNotFound
+		}
+
+		return unavailability.WindowModel{}, err
+	}
+
+	return res, nil
+}
+
+func (up *UnavailabilityPostgres) ListWindows(ctx context.Context, userID string) ([]unavailability.WindowModel, error) {
+	var exists bool
+
+	if err := up.conn.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, apperrors.ErrNotFound
+	}
+
+	rows, err := up.conn.Query(ctx, `
+		SELECT `+windowColumns+`
+		FROM user_unavailability
+		WHERE user_id = $1
+		ORDER BY starts_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []unavailability.WindowModel
+
+	for rows.Next() {
+		w, err := scanWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, rows.Err()
+}
+
+func (up *UnavailabilityPostgres) UpdateWindow(ctx context.Context,
+	w unavailability.WindowModel) (unavailability.WindowModel, error) {
+	// moving the start into the future re-arms the handover job
+	res, err := scanWindow(up.conn.QueryRow(ctx, `
+		UPDATE user_unavailability
+		SET starts_at = $3,
+			ends_at = $4,
+			reason = NULLIF($5, ''),
+			handled_at = CASE WHEN $3 > NOW() THEN NULL ELSE handled_at END
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+windowColumns,
+		w.ID, w.UserID, w.StartsAt, w.EndsAt, w.Reason))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return unavailability.WindowModel{}, apperrors.ErrNotFound
+		}
+
+		return unavailability.WindowModel{}, err
+	}
+
+	return res, nil
+}
+
+func (up *UnavailabilityPostgres) DeleteWindow(ctx context.Context, userID string, id int64) error {
+	tag, err := up.conn.Exec(ctx,
+		"DELETE FROM user_unavailability WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (up *UnavailabilityPostgres) HandOverStarted(ctx context.Context) ([]unavailability.HandoverModel, error) {
+	var (
+		handovers []unavailability.HandoverModel
+		failed    []int64
+		errs      []error
+	)
+
+	// one window per transaction, so a failing handover does not hold
+	// back the others; it is skipped for the rest of the run and retried
+	// on the next one
+	for {
+		h, ok, err := up.handOverNext(ctx, failed)
+		if err != nil {
+			if h.WindowID == 0 {
+				return handovers, errors.Join(append(errs, err)...)
+			}
+
+			errs = append(errs, fmt.Errorf("window %d: %w", h.WindowID, err))
+			failed = append(failed, h.WindowID)
+
+			continue
+		}
+
+		if !ok {
+			return handovers, errors.Join(errs...)
+		}
+
+		handovers = append(handovers, h)
+	}
+}
+
+// handOverNext handles the first due window not in skip. When the
+// handover of a picked window fails, the returned model carries its id.
+func (up *UnavailabilityPostgres) handOverNext(ctx context.Context,
+	skip []int64) (unavailability.HandoverModel, bool, error) {
+	tx, err := up.conn.Begin(ctx)
+	if err != nil {
+		return unavailability.HandoverModel{}, false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var h unavailability.HandoverModel
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id
+		FROM user_unavailability
+		WHERE handled_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()
+		  AND id != ALL($1)
+		ORDER BY starts_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`, append([]int64{}, skip...)).Scan(&h.WindowID, &h.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return unavailability.HandoverModel{}, false, nil
+		}
+
+		return unavailability.HandoverModel{}, false, err
+	}
+
+	open, err := assign.LockOpenReviews(ctx, tx, h.UserID)
+	if err != nil {
+		return unavailability.HandoverModel{WindowID: h.WindowID}, false, err
+	}
+
+	for _, prm := range open {
+		replacement, err := assign.ReplaceReviewer(ctx, tx, prm, h.UserID,
+			assign.ReplaceOptions{Reason: pr.ReasonUserUnavailable}, up.policy)
+		if assign.Unfillable(err) {
+			h.Kept++
+			continue
+		}
+
+		if err != nil {
+			return unavailability.HandoverModel{WindowID: h.WindowID}, false, err
+		}
+
+		h.Reassigned = append(h.Reassigned, user.ReassignedReview{
+			PRID:       prm.ID,
+			PRName:     prm.Name,
+			AuthorID:   prm.AuthorID,
+			ReviewerID: h.UserID,
+			ReplacedBy: replacement.ReviewerID,
+		})
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE user_unavailability SET handled_at = NOW() WHERE id = $1", h.WindowID); err != nil {
+		return unavailability.HandoverModel{WindowID: h.WindowID}, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return unavailability.HandoverModel{WindowID: h.WindowID}, false, err
+	}
+
+	return h, true, nil
+}
+
+func NewUnavailabilityPostgres(conn *pgxpool.Pool, log *slog.Logger,
+	policy pr.AssignmentPolicy) *UnavailabilityPostgres {
+	return &UnavailabilityPostgres{
+		conn:   conn,
+		log:    log,
+		policy: policy,
+	}
+}
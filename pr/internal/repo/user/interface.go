@@ -2,10 +2,36 @@ package user
 
 import (
 	"context"
+	"pr/internal/repo/page"
 	"pr/internal/repo/pr"
 )
 
 type UserInterface interface {
+	GetUser(ctx context.Context, id string) (UserModel, error)
 	SetUserStatus(ctx context.Context, id string, isActive bool) (UserModel, error)
-	GetPrUser(ctx context.Context, userID string) ([]pr.PRModel, error)
+	GetPrUser(ctx context.Context, userID string, filter ReviewFilter,
+		p page.Request) ([]pr.PRWithDateModel, page.Info, error)
+	// MoveTeam moves the user to another team. policy decides what happens
+	// to the user's reviews of open PRs: they are kept, reassigned within
+	// the PR author's team (a PR nobody can take just loses the reviewer),
+	// or make the move fail.
+	MoveTeam(ctx context.Context, userID, teamName, policy string) (UserModel, string, []ReassignedReview, error)
+	GetUserHistory(ctx context.Context, userID string) ([]HistoryModel, error)
+	// DeleteUser soft-deletes and deactivates the user, handing their open
+	// reviews to teammates of each PR author. Reviews nobody can take are
+	// unassigned.
+	DeleteUser(ctx context.Context, userID string) (UserModel, []ReassignedReview, error)
+	RestoreUser(ctx context.Context, userID string) (UserModel, error)
+	// SetUserSettings replaces the user's assignment settings. A nil
+	// maxOpenReviews removes the limit.
+	SetUserSettings(ctx context.Context, userID string, maxOpenReviews *int) (UserModel, error)
+	GetNotificationSettings(ctx context.Context, userID string) (NotificationSettings, error)
+	SetNotificationSettings(ctx context.Context, userID string, settings NotificationSettings) error
+	// ClaimDueDigests returns the digests whose local time has come today
+	// and leases them to the caller. A digest not marked sent before the
+	// lease runs out is returned again.
+	ClaimDueDigests(ctx context.Context) ([]DueDigest, error)
+	// MarkDigestSent records that the claimed digest went out, so none is
+	// due again until its next local day.
+	MarkDigestSent(ctx context.Context, due DueDigest) error
 }
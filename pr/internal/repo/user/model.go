@@ -1,8 +1,118 @@
 package user
 
+import (
+	"slices"
+	"time"
+)
+
 type UserModel struct {
-	ID       string
-	UserName string
-	TeamName string
+	ID        string
+	UserName  string
+	TeamName  string
+	CreatedAt time.Time
+	DeletedAt *time.Time
+	// MaxOpenReviews caps the user's reviews of open PRs; nil is unlimited
+	MaxOpenReviews *int
+	// Skills are tag names; on writes nil keeps the current skills
+	Skills []string
+	// Role is the user's seniority, one of Roles; empty if not set, and on
+	// writes empty keeps the current role
+	Role     string
 	IsActive bool
 }
+
+// seniority roles, lowest first
+const (
+	RoleJunior = "junior"
+	RoleMiddle = "middle"
+	RoleSenior = "senior"
+	RoleLead   = "lead"
+)
+
+// Roles lists the roles from the lowest to the highest.
+var Roles = []string{RoleJunior, RoleMiddle, RoleSenior, RoleLead}
+
+// RolesFrom returns role and the roles above it; nil for an unknown role.
+func RolesFrom(role string) []string {
+	i := slices.Index(Roles, role)
+	if i < 0 {
+		return nil
+	}
+
+	return Roles[i:]
+}
+
+// ReviewFilter narrows the PRs a user reviews. Zero values match everything.
+type ReviewFilter struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Status        string
+	AuthorID      string
+}
+
+// how open reviews are handled when a user moves to another team
+const (
+	MovePolicyKeep     = "keep"
+	MovePolicyReassign = "reassign"
+	MovePolicyFail     = "fail"
+)
+
+// history events
+const (
+	EventTeamMoved = "TEAM_MOVED"
+	// the user was removed from from_team and left without a team
+	EventTeamRemoved = "TEAM_REMOVED"
+	EventDeleted     = "DELETED"
+	EventRestored    = "RESTORED"
+)
+
+// NotificationSettings say how the user is notified. Default is set when
+// the user never saved settings; the notifier then uses its defaults.
+type NotificationSettings struct {
+	Channels   []string
+	Events     []string
+	Email      string
+	WebhookURL string
+	Digest     DigestSettings
+	Default    bool
+}
+
+// DigestSettings schedule the daily digest of the user's open reviews.
+// Time is the local "15:04" time in TimeZone, Days are ISO weekdays
+// (1 is Monday) and empty Days means every day. An empty Channel sends
+// through the service default.
+type DigestSettings struct {
+	Time     string
+	TimeZone string
+	Channel  string
+	Days     []int
+	Enabled  bool
+}
+
+// DueDigest is a digest the user is due to receive now.
+type DueDigest struct {
+	UserID   string
+	TimeZone string
+	Channel  string
+	// On is the user's local date the digest is for
+	On time.Time
+}
+
+// ReassignedReview is a review handed over from ReviewerID to
+// ReplacedBy, which is empty when nobody could take it.
+type ReassignedReview struct {
+	PRID       string
+	PRName     string
+	AuthorID   string
+	ReviewerID string
+	ReplacedBy string
+}
+
+type HistoryModel struct {
+	CreatedAt time.Time
+	Event     string
+	FromTeam  string
+	ToTeam    string
+	Policy    string
+	ID        int64
+}
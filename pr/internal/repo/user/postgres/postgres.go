@@ -3,27 +3,34 @@ package userpostgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	apperrors "pr/internal/app/errors"
+	"pr/internal/repo/assign"
+	"pr/internal/repo/page"
 	"pr/internal/repo/pr"
 	"pr/internal/repo/user"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserPostgres struct {
-	conn *pgxpool.Pool
-	log  *slog.Logger
+	conn   *pgxpool.Pool
+	log    *slog.Logger
+	policy pr.AssignmentPolicy
 }
 
-func (u *UserPostgres) SetUserStatus(ctx context.Context, id string, isActive bool) (user.UserModel, error) {
-	sql := `UPDATE users SET is_active = $1 WHERE id = $2 RETURNING id, username, team_name, is_active`
+func (u *UserPostgres) GetUser(ctx context.Context, id string) (user.UserModel, error) {
+	sql := `SELECT id, username, COALESCE(team_name, ''), is_active, deleted_at, max_open_reviews
+	FROM users WHERE id = $1`
 
 	var res user.UserModel
 
-	if err := u.conn.QueryRow(ctx, sql, isActive, id).Scan(&res.ID, &res.UserName,
-		&res.TeamName, &res.IsActive); err != nil {
+	if err := u.conn.QueryRow(ctx, sql, id).Scan(&res.ID, &res.UserName,
+		&res.TeamName, &res.IsActive, &res.DeletedAt, &res.MaxOpenReviews); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return user.UserModel{}, apperrors.ErrNotFound
 		}
@@ -34,42 +41,540 @@ func (u *UserPostgres) SetUserStatus(ctx context.Context, id string, isActive bo
 	return res, nil
 }
 
-func (u *UserPostgres) GetPrUser(ctx context.Context, userID string) ([]pr.PRModel, error) {
-	var prs []pr.PRModel
+func (u *UserPostgres) SetUserStatus(ctx context.Context, id string, isActive bool) (user.UserModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return user.UserModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	sql := `
-	SELECT pr.id, pr.name, pr.author_id, pr.status
+	WITH prev AS (
+		SELECT id, is_active FROM users WHERE id = $2 AND deleted_at IS NULL FOR UPDATE
+	)
+	UPDATE users AS u
+	SET is_active = $1
+	FROM prev
+	WHERE u.id = prev.id
+	RETURNING u.id, u.username, COALESCE(u.team_name, ''), u.is_active, u.max_open_reviews, prev.is_active`
+
+	var (
+		res       user.UserModel
+		wasActive bool
+	)
+
+	if err := tx.QueryRow(ctx, sql, isActive, id).Scan(&res.ID, &res.UserName,
+		&res.TeamName, &res.IsActive, &res.MaxOpenReviews, &wasActive); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.UserModel{}, apperrors.ErrNotFound
+		}
+
+		return user.UserModel{}, err
+	}
+
+	// member activity is part of the team representation
+	if wasActive != res.IsActive {
+		if _, err := tx.Exec(ctx, "UPDATE teams SET version = version + 1 WHERE name = $1", res.TeamName); err != nil {
+			return user.UserModel{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return user.UserModel{}, err
+	}
+
+	return res, nil
+}
+
+func (u *UserPostgres) GetPrUser(ctx context.Context, userID string, filter user.ReviewFilter,
+	p page.Request) ([]pr.PRWithDateModel, page.Info, error) {
+	after, err := p.After()
+	if err != nil {
+		return nil, page.Info{}, err
+	}
+
+	where := []string{"upr.users_id = $1"}
+	args := []any{userID}
+
+	add := func(cond string, val any) {
+		args = append(args, val)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Status != "" {
+		add("pr.status = $%d", filter.Status)
+	}
+
+	if filter.AuthorID != "" {
+		add("pr.author_id = $%d", filter.AuthorID)
+	}
+
+	if filter.CreatedAfter != nil {
+		add("pr.created_at >= $%d", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		add("pr.created_at < $%d", *filter.CreatedBefore)
+	}
+
+	if after != nil {
+		cond, condArgs := after.Where("pr.created_at", "pr.id", len(args)+1)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	args = append(args, p.Fetch())
+
+	sql := fmt.Sprintf(`
+	SELECT pr.id, pr.name, pr.author_id, pr.status, pr.created_at, pr.merged_at, pr.version
 	FROM users_pull_requests AS upr
 		JOIN pull_requests AS pr
 			ON upr.pull_requests_id = pr.id
-	WHERE upr.users_id = $1`
+	WHERE %s
+	%s
+	LIMIT $%d`, strings.Join(where, " AND "), p.OrderBy("pr.created_at", "pr.id"), len(args))
 
-	rows, err := u.conn.Query(ctx, sql, userID)
+	rows, err := u.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, page.Info{}, err
+	}
+
+	defer rows.Close()
+
+	var prs []pr.PRWithDateModel
+
+	for rows.Next() {
+		var body pr.PRWithDateModel
+		if err := rows.Scan(&body.ID, &body.Name, &body.AuthorID, &body.Status,
+			&body.CreatedAt, &body.MergedAt, &body.Version); err != nil {
+			return nil, page.Info{}, err
+		}
+
+		prs = append(prs, body)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, page.Info{}, err
+	}
+
+	prs, info := page.Slice(p, prs, func(m pr.PRWithDateModel) (time.Time, string) {
+		return *m.CreatedAt, m.ID
+	})
+
+	return prs, info, nil
+}
+
+func (u *UserPostgres) MoveTeam(ctx context.Context, userID, teamName,
+	policy string) (user.UserModel, string, []user.ReassignedReview, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var res user.UserModel
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, username, COALESCE(team_name, ''), is_active, created_at, max_open_reviews
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`, userID).Scan(&res.ID, &res.UserName, &res.TeamName, &res.IsActive, &res.CreatedAt,
+		&res.MaxOpenReviews)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apperrors.ErrNotFound
+			return user.UserModel{}, "", nil, apperrors.ErrNotFound
+		}
+
+		return user.UserModel{}, "", nil, err
+	}
+
+	var teamExists bool
+
+	if err := tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM teams WHERE name = $1)", teamName).Scan(&teamExists); err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	if !teamExists {
+		return user.UserModel{}, "", nil, apperrors.ErrNotFound
+	}
+
+	fromTeam := res.TeamName
+	if fromTeam == teamName {
+		return res, fromTeam, nil, nil
+	}
+
+	var reassigned []user.ReassignedReview
+
+	if policy != user.MovePolicyKeep {
+		open, err := assign.LockOpenReviews(ctx, tx, userID)
+		if err != nil {
+			return user.UserModel{}, "", nil, err
+		}
+
+		handOver, err := assign.MoveHandOver(policy, open)
+		if err != nil {
+			return user.UserModel{}, "", nil, err
+		}
+
+		// PRs nobody can take over lose the reviewer instead of failing the
+		// move; they are reported without a replacement
+		reassigned, err = assign.HandOverReviews(ctx, tx, handOver, userID, pr.ReasonTeamMove, u.policy)
+		if err != nil {
+			return user.UserModel{}, "", nil, err
 		}
+	}
 
+	if _, err := tx.Exec(ctx, "UPDATE users SET team_name = $1 WHERE id = $2", teamName, userID); err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE teams SET version = version + 1 WHERE name = ANY($1)", []string{fromTeam, teamName}); err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	if err := addHistory(ctx, tx, userID, user.EventTeamMoved, fromTeam, teamName, policy); err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return user.UserModel{}, "", nil, err
+	}
+
+	res.TeamName = teamName
+
+	return res, fromTeam, reassigned, nil
+}
+
+func addHistory(ctx context.Context, tx pgx.Tx, userID, event, fromTeam, toTeam, policy string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO user_history (user_id, event, from_team, to_team, policy)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''))`,
+		userID, event, fromTeam, toTeam, policy)
+
+	return err
+}
+
+func (u *UserPostgres) DeleteUser(ctx context.Context, userID string) (user.UserModel, []user.ReassignedReview, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var res user.UserModel
+
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET deleted_at = NOW(), is_active = false
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, username, COALESCE(team_name, ''), is_active, created_at, deleted_at, max_open_reviews`,
+		userID).Scan(&res.ID, &res.UserName, &res.TeamName, &res.IsActive, &res.CreatedAt, &res.DeletedAt,
+		&res.MaxOpenReviews)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.UserModel{}, nil, apperrors.ErrNotFound
+		}
+
+		return user.UserModel{}, nil, err
+	}
+
+	open, err := assign.LockOpenReviews(ctx, tx, userID)
+	if err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	reassigned, err := assign.HandOverReviews(ctx, tx, open, userID, pr.ReasonUserDeleted, u.policy)
+	if err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE teams SET version = version + 1 WHERE name = $1", res.TeamName); err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	if err := addHistory(ctx, tx, userID, user.EventDeleted, res.TeamName, "", ""); err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return user.UserModel{}, nil, err
+	}
+
+	return res, reassigned, nil
+}
+
+// RestoreUser brings a deleted user back. The user stays inactive until
+// explicitly activated.
+func (u *UserPostgres) RestoreUser(ctx context.Context, userID string) (user.UserModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return user.UserModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var res user.UserModel
+
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, username, COALESCE(team_name, ''), is_active, created_at, max_open_reviews`,
+		userID).Scan(&res.ID, &res.UserName, &res.TeamName, &res.IsActive, &res.CreatedAt, &res.MaxOpenReviews)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.UserModel{}, apperrors.ErrNotFound
+		}
+
+		return user.UserModel{}, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE teams SET version = version + 1 WHERE name = $1", res.TeamName); err != nil {
+		return user.UserModel{}, err
+	}
+
+	if err := addHistory(ctx, tx, userID, user.EventRestored, "", res.TeamName, ""); err != nil {
+		return user.UserModel{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return user.UserModel{}, err
+	}
+
+	return res, nil
+}
+
+// SetUserSettings only changes future assignments: reviews already above a
+// lowered limit stay assigned.
+func (u *UserPostgres) SetUserSettings(ctx context.Context, userID string,
+	maxOpenReviews *int) (user.UserModel, error) {
+	tx, err := u.conn.Begin(ctx)
+	if err != nil {
+		return user.UserModel{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var res user.UserModel
+
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET max_open_reviews = $2
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, username, COALESCE(team_name, ''), is_active, created_at, max_open_reviews`,
+		userID, maxOpenReviews).Scan(&res.ID, &res.UserName, &res.TeamName, &res.IsActive, &res.CreatedAt,
+		&res.MaxOpenReviews)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.UserModel{}, apperrors.ErrNotFound
+		}
+
+		return user.UserModel{}, err
+	}
+
+	// the limit is part of the team member representation
+	if _, err := tx.Exec(ctx, "UPDATE teams SET version = version + 1 WHERE name = $1", res.TeamName); err != nil {
+		return user.UserModel{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return user.UserModel{}, err
+	}
+
+	return res, nil
+}
+
+func (u *UserPostgres) GetNotificationSettings(ctx context.Context, userID string) (user.NotificationSettings, error) {
+	var (
+		res    user.NotificationSettings
+		exists bool
+	)
+
+	err := u.conn.QueryRow(ctx, `
+		SELECT n.user_id IS NOT NULL, COALESCE(n.channels, '{}'), COALESCE(n.events, '{}'),
+			COALESCE(n.email, ''), COALESCE(n.webhook_url, ''),
+			COALESCE(n.digest_enabled, false), to_char(COALESCE(n.digest_time, '09:00'), 'HH24:MI'),
+			COALESCE(n.digest_time_zone, 'UTC'), COALESCE(n.digest_days, '{}'), COALESCE(n.digest_channel, '')
+		FROM users AS u
+			LEFT JOIN notification_settings AS n
+				ON n.user_id = u.id
+		WHERE u.id = $1 AND u.deleted_at IS NULL`,
+		userID).Scan(&exists, &res.Channels, &res.Events, &res.Email, &res.WebhookURL,
+		&res.Digest.Enabled, &res.Digest.Time, &res.Digest.TimeZone, &res.Digest.Days, &res.Digest.Channel)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.NotificationSettings{}, apperrors.ErrNotFound
+		}
+
+		return user.NotificationSettings{}, err
+	}
+
+	res.Default = !exists
+
+	return res, nil
+}
+
+func (u *UserPostgres) SetNotificationSettings(ctx context.Context, userID string,
+	settings user.NotificationSettings) error {
+	// Go and postgres ship their own zone databases; the digest schedule
+	// is computed by postgres, so it has the final say
+	var known bool
+
+	if err := u.conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)",
+		settings.Digest.TimeZone).Scan(&known); err != nil {
+		return err
+	}
+
+	if !known {
+		return apperrors.NewValidationError([]apperrors.FieldError{{
+			Field:   "digest.time_zone",
+			Code:    "INVALID_FORMAT",
+			Message: "must be an IANA time zone such as Europe/Berlin",
+		}})
+	}
+
+	tag, err := u.conn.Exec(ctx, `
+		INSERT INTO notification_settings (user_id, channels, events, email, webhook_url,
+			digest_enabled, digest_time, digest_time_zone, digest_days, digest_channel)
+		SELECT id, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7::time, $8, $9, NULLIF($10, '')
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (user_id) DO UPDATE
+		SET channels = EXCLUDED.channels,
+			events = EXCLUDED.events,
+			email = EXCLUDED.email,
+			webhook_url = EXCLUDED.webhook_url,
+			digest_enabled = EXCLUDED.digest_enabled,
+			digest_time = EXCLUDED.digest_time,
+			digest_time_zone = EXCLUDED.digest_time_zone,
+			digest_days = EXCLUDED.digest_days,
+			digest_channel = EXCLUDED.digest_channel,
+			updated_at = NOW()`,
+		userID, settings.Channels, settings.Events, settings.Email, settings.WebhookURL,
+		settings.Digest.Enabled, settings.Digest.Time, settings.Digest.TimeZone, settings.Digest.Days,
+		settings.Digest.Channel)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+
+	return nil
+}
+
+// digestLease is how long a claimed digest waits for MarkDigestSent
+// before another run claims it again.
+const digestLease = 10 * time.Minute
+
+func (u *UserPostgres) ClaimDueDigests(ctx context.Context) ([]user.DueDigest, error) {
+	// a digest is due once the local time passes digest_time on a
+	// scheduled weekday, unless one went out on that local date already
+	// or another run holds it; zones postgres doesn't know are skipped
+	// rather than failing the whole statement
+	rows, err := u.conn.Query(ctx, `
+		UPDATE notification_settings AS n
+		SET digest_claimed_at = NOW()
+		FROM users AS u
+		WHERE u.id = n.user_id
+			AND u.deleted_at IS NULL
+			AND u.is_active
+			AND n.digest_enabled
+			AND n.digest_time_zone IN (SELECT name FROM pg_timezone_names)
+			AND (n.digest_claimed_at IS NULL OR n.digest_claimed_at < NOW() - $1::interval)
+			AND (NOW() AT TIME ZONE n.digest_time_zone)::time >= n.digest_time
+			AND (n.digest_sent_on IS NULL
+				OR n.digest_sent_on < (NOW() AT TIME ZONE n.digest_time_zone)::date)
+			AND (cardinality(n.digest_days) = 0
+				OR EXTRACT(ISODOW FROM NOW() AT TIME ZONE n.digest_time_zone)::smallint = ANY(n.digest_days))
+		RETURNING n.user_id, n.digest_time_zone, COALESCE(n.digest_channel, ''),
+			(NOW() AT TIME ZONE n.digest_time_zone)::date`,
+		digestLease)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var due []user.DueDigest
+
+	for rows.Next() {
+		var d user.DueDigest
+		if err := rows.Scan(&d.UserID, &d.TimeZone, &d.Channel, &d.On); err != nil {
+			return nil, err
+		}
+
+		due = append(due, d)
+	}
+
+	return due, rows.Err()
+}
+
+func (u *UserPostgres) MarkDigestSent(ctx context.Context, due user.DueDigest) error {
+	_, err := u.conn.Exec(ctx, `
+		UPDATE notification_settings
+		SET digest_sent_on = $2, digest_claimed_at = NULL
+		WHERE user_id = $1`,
+		due.UserID, due.On)
 
+	return err
+}
+
+func (u *UserPostgres) GetUserHistory(ctx context.Context, userID string) ([]user.HistoryModel, error) {
+	var exists bool
+
+	if err := u.conn.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, apperrors.ErrNotFound
+	}
+
+	rows, err := u.conn.Query(ctx, `
+		SELECT id, event, COALESCE(from_team, ''), COALESCE(to_team, ''), COALESCE(policy, ''), created_at
+		FROM user_history
+		WHERE user_id = $1
+		ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	var history []user.HistoryModel
+
 	for rows.Next() {
-		var body pr.PRModel
-		if err := rows.Scan(&body.ID, &body.Name, &body.AuthorID, &body.Status); err != nil {
+		var h user.HistoryModel
+		if err := rows.Scan(&h.ID, &h.Event, &h.FromTeam, &h.ToTeam, &h.Policy, &h.CreatedAt); err != nil {
 			return nil, err
 		}
 
-		prs = append(prs, body)
+		history = append(history, h)
 	}
 
-	return prs, nil
+	return history, rows.Err()
 }
 
-func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger) user.UserInterface {
+func NewPrPostgres(conn *pgxpool.Pool, log *slog.Logger, policy pr.AssignmentPolicy) user.UserInterface {
 	return &UserPostgres{
-		conn: conn,
-		log:  log,
+		conn:   conn,
+		log:    log,
+		policy: policy,
 	}
 }
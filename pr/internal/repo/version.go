@@ -0,0 +1,11 @@
+package repo
+
+// AnyVersion as an expected version matches whatever version is stored,
+// it only requires the resource to exist (If-Match: *).
+const AnyVersion int64 = 0
+
+// VersionMatches reports whether an optimistic concurrency check passes.
+// A nil expected version disables the check.
+func VersionMatches(expected *int64, actual int64) bool {
+	return expected == nil || *expected == AnyVersion || *expected == actual
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"pr/internal/repo/lock"
+	"sync"
+	"time"
+)
+
+// Scheduler runs periodic jobs. Each run holds a lock named after the job,
+// so with several instances of the service a job runs on one of them at a
+// time and the others skip that tick.
+type Scheduler struct {
+	locks lock.LockInterface
+	log   *slog.Logger
+	jobs  []job
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+}
+
+func New(locks lock.LockInterface, log *slog.Logger) *Scheduler {
+	return &Scheduler{
+		locks: locks,
+		log:   log,
+	}
+}
+
+// Add registers run to be called every interval. Jobs must be added
+// before Run.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Run runs the jobs until ctx is cancelled and the running ones return.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, j := range s.jobs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			s.loop(ctx, j)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			locked, err := s.locks.WithLock(ctx, "job:"+j.name, j.run)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.log.ErrorContext(ctx, "Failed to lock scheduled job",
+						slog.String("job", j.name), slog.Any("error", err))
+				}
+
+				continue
+			}
+
+			if !locked {
+				s.log.DebugContext(ctx, "Scheduled job is running on another instance",
+					slog.String("job", j.name))
+			}
+		}
+	}
+}
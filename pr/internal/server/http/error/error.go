@@ -1,26 +1,30 @@
 package errorhandler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
 	apperrors "pr/internal/app/errors"
 	"pr/internal/dto"
+	"pr/internal/logctx"
 )
 
-func WriteError(w http.ResponseWriter, err error, log *slog.Logger) {
+func WriteError(ctx context.Context, w http.ResponseWriter, err error, log *slog.Logger) {
 	var appErr *apperrors.AppError
 	if errors.As(err, &appErr) {
 		resp := dto.ErrorResponse{
-			Error: *appErr,
+			Error:     *appErr,
+			RequestID: logctx.ReqID(ctx),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(appErr.Status)
 
-		if encodeErr := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Error("Failed to encode error response", slog.Any("original_error", err), slog.Any("encode_error", encodeErr))
+		if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+			log.ErrorContext(ctx, "Failed to encode error response",
+				slog.Any("original_error", err), slog.Any("encode_error", encodeErr))
 		}
 
 		return
@@ -30,9 +34,10 @@ func WriteError(w http.ResponseWriter, err error, log *slog.Logger) {
 	w.WriteHeader(http.StatusInternalServerError)
 
 	if encodeErr := json.NewEncoder(w).Encode(dto.ErrorResponse{
-		Error: *apperrors.ErrInternalServer,
-	}); err != nil {
-		log.Error("Failed to encode internal error response",
+		Error:     *apperrors.ErrInternalServer,
+		RequestID: logctx.ReqID(ctx),
+	}); encodeErr != nil {
+		log.ErrorContext(ctx, "Failed to encode internal error response",
 			slog.Any("original_error", err), slog.Any("encode_error", encodeErr))
 	}
 }
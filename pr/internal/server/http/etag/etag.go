@@ -0,0 +1,43 @@
+package etag
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/repo"
+)
+
+// Set writes the resource version as a strong ETag.
+func Set(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// IfMatch returns the version required by the If-Match header, nil when the
+// header is absent and repo.AnyVersion for "*". Weak tags are accepted since
+// versions are compared as plain numbers. A value that cannot match any
+// version fails the precondition.
+func IfMatch(r *http.Request) (*int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" {
+		return nil, nil //nolint:nilnil // absent header means no precondition
+	}
+
+	if raw == "*" {
+		v := repo.AnyVersion
+		return &v, nil
+	}
+
+	tag := strings.TrimPrefix(raw, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return nil, apperrors.ErrPreconditionFailed
+	}
+
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || v <= 0 {
+		return nil, apperrors.ErrPreconditionFailed
+	}
+
+	return &v, nil
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/repo/idempotency"
+	errorhandler "pr/internal/server/http/error"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReplayed       = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	maxIdempotentBody    = 1 << 20
+)
+
+// Idempotency makes POST requests carrying an Idempotency-Key safe to retry.
+// The first request with a key is executed and its response stored for ttl;
+// repeats with the same body get the stored response replayed, repeats with
+// a different body are rejected with 422. Only 2xx responses are stored, so
+// a retry after an error runs the request again.
+func Idempotency(store idempotency.IdempotencyInterface, ttl, lockTimeout time.Duration,
+	log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			if len(key) > maxIdempotencyKeyLen {
+				errorhandler.WriteError(ctx, w, apperrors.NewValidationError([]apperrors.FieldError{{
+					Field:   HeaderIdempotencyKey,
+					Code:    "TOO_LONG",
+					Message: "must be at most 255 characters",
+				}}), log)
+
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil || len(body) > maxIdempotentBody {
+				log.WarnContext(ctx, "Failed to read request body", slog.Any("error", err))
+				errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r, body)
+
+			rec, reserved, err := store.Reserve(ctx, key, hash, ttl, lockTimeout)
+			if err != nil {
+				log.ErrorContext(ctx, "Failed to reserve idempotency key", slog.Any("error", err))
+				errorhandler.WriteError(ctx, w, err, log)
+
+				return
+			}
+
+			if !reserved {
+				replay(ctx, w, rec, hash, log)
+				return
+			}
+
+			rw := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+
+			if status := rw.statusCode(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+				if err := store.Release(storeCtx, key); err != nil {
+					log.ErrorContext(ctx, "Failed to release idempotency key", slog.Any("error", err))
+				}
+
+				return
+			}
+
+			headers := w.Header().Clone()
+			headers.Del(HeaderRequestID)
+
+			if err := store.Complete(storeCtx, key, rw.statusCode(), headers, rw.body.Bytes()); err != nil {
+				log.ErrorContext(ctx, "Failed to store idempotent response", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+func replay(ctx context.Context, w http.ResponseWriter, rec idempotency.RecordModel, hash string, log *slog.Logger) {
+	switch {
+	case rec.RequestHash != hash:
+		log.WarnContext(ctx, "Idempotency key reused with a different request")
+		errorhandler.WriteError(ctx, w, apperrors.ErrIdempotencyKeyReused, log)
+	case !rec.Completed:
+		errorhandler.WriteError(ctx, w, apperrors.ErrIdempotencyInProgress, log)
+	default:
+		for name, values := range rec.Headers {
+			w.Header()[name] = values
+		}
+
+		w.Header().Set(HeaderReplayed, "true")
+		w.WriteHeader(rec.StatusCode)
+
+		if _, err := w.Write(rec.Body); err != nil {
+			log.ErrorContext(ctx, "Failed to write replayed response", slog.Any("error", err))
+		}
+
+		log.InfoContext(ctx, "Replayed idempotent response", slog.Int("status", rec.StatusCode))
+	}
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if rr.status == 0 {
+		rr.status = code
+	}
+
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+
+	rr.body.Write(b)
+
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) statusCode() int {
+	if rr.status == 0 {
+		return http.StatusOK
+	}
+
+	return rr.status
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pr/internal/dto"
+	"pr/internal/repo/idempotency"
+	"strings"
+	"testing"
+	"time"
+)
+
+type storeStub struct {
+	records map[string]idempotency.RecordModel
+}
+
+func newStoreStub() *storeStub {
+	return &storeStub{records: make(map[string]idempotency.RecordModel)}
+}
+
+func (s *storeStub) Reserve(_ context.Context, key, requestHash string, _, _ time.Duration) (
+	idempotency.RecordModel, bool, error) {
+	if rec, ok := s.records[key]; ok {
+		return rec, false, nil
+	}
+
+	s.records[key] = idempotency.RecordModel{Key: key, RequestHash: requestHash}
+
+	return idempotency.RecordModel{}, true, nil
+}
+
+func (s *storeStub) Complete(_ context.Context, key string, status int, headers http.Header, body []byte) error {
+	rec := s.records[key]
+	rec.StatusCode, rec.Headers, rec.Body, rec.Completed = status, headers, body, true
+	s.records[key] = rec
+
+	return nil
+}
+
+func (s *storeStub) Release(_ context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func (s *storeStub) DeleteExpired(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// countingHandler answers with the next status from statuses and counts
+// the requests that reached it.
+type countingHandler struct {
+	statuses []int
+	calls    int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	status := h.statuses[min(h.calls, len(h.statuses)-1)]
+	h.calls++
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `{"call":%d}`, h.calls)
+}
+
+func serveIdempotent(t *testing.T, h http.Handler, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body))
+	req.Header.Set(HeaderIdempotencyKey, key)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func errorCodeOf(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response %q: %v", rec.Body.String(), err)
+	}
+
+	return resp.Error.Code
+}
+
+func idempotent(store idempotency.IdempotencyInterface, next http.Handler) http.Handler {
+	return Idempotency(store, time.Hour, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))(next)
+}
+
+func TestIdempotencyReplaysSameRequest(t *testing.T) {
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	h := idempotent(newStoreStub(), next)
+
+	first := serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`)
+	retried := serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`)
+
+	if next.calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", next.calls)
+	}
+
+	if retried.Code != http.StatusCreated || !bytes.Equal(first.Body.Bytes(), retried.Body.Bytes()) {
+		t.Errorf("retry = %d %q, want %d %q", retried.Code, retried.Body, first.Code, first.Body)
+	}
+
+	if retried.Header().Get(HeaderReplayed) != "true" || first.Header().Get(HeaderReplayed) != "" {
+		t.Errorf("Idempotent-Replayed = %q on the retry and %q on the first response",
+			retried.Header().Get(HeaderReplayed), first.Header().Get(HeaderReplayed))
+	}
+
+	if ct := retried.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("replayed Content-Type = %q", ct)
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	h := idempotent(newStoreStub(), next)
+
+	serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`)
+	rec := serveIdempotent(t, h, "key-1", `{"id":"pr-2"}`)
+
+	if rec.Code != http.StatusUnprocessableEntity || errorCodeOf(t, rec) != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("reuse = %d %q, want 422 IDEMPOTENCY_KEY_REUSED", rec.Code, rec.Body)
+	}
+
+	if next.calls != 1 {
+		t.Errorf("handler ran %d times, want 1", next.calls)
+	}
+}
+
+func TestIdempotencyRejectsRequestInProgress(t *testing.T) {
+	store := newStoreStub()
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	h := idempotent(store, next)
+
+	// the first request holds the key until its handler returns
+	var inner *httptest.ResponseRecorder
+
+	outer := serveIdempotent(t, idempotent(store, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		inner = serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`)
+		w.WriteHeader(http.StatusCreated)
+	})), "key-1", `{"id":"pr-1"}`)
+
+	if inner.Code != http.StatusConflict || errorCodeOf(t, inner) != "IDEMPOTENCY_IN_PROGRESS" {
+		t.Errorf("concurrent retry = %d %q, want 409 IDEMPOTENCY_IN_PROGRESS", inner.Code, inner.Body)
+	}
+
+	if outer.Code != http.StatusCreated || next.calls != 0 {
+		t.Errorf("first request = %d, concurrent handler calls = %d", outer.Code, next.calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			store := newStoreStub()
+			next := &countingHandler{statuses: []int{status, http.StatusCreated}}
+			h := idempotent(store, next)
+
+			if rec := serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`); rec.Code != status {
+				t.Fatalf("first = %d, want %d", rec.Code, status)
+			}
+
+			if _, ok := store.records["key-1"]; ok {
+				t.Fatalf("key is still held after a %d", status)
+			}
+
+			rec := serveIdempotent(t, h, "key-1", `{"id":"pr-1"}`)
+			if rec.Code != http.StatusCreated || rec.Header().Get(HeaderReplayed) != "" || next.calls != 2 {
+				t.Errorf("retry = %d (replayed %q), handler calls = %d; want a fresh 201",
+					rec.Code, rec.Header().Get(HeaderReplayed), next.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyPassesThroughWithoutKey(t *testing.T) {
+	store := newStoreStub()
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	h := idempotent(store, next)
+
+	for range 2 {
+		h.ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(`{}`)))
+	}
+
+	if next.calls != 2 || len(store.records) != 0 {
+		t.Errorf("handler calls = %d, stored keys = %d; want 2 and 0", next.calls, len(store.records))
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"pr/internal/logctx"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID   = "X-Request-ID"
+	headerTraceparent = "traceparent"
+
+	maxRequestIDLen = 128
+)
+
+// RequestID puts a request ID into the request context and echoes it in the
+// X-Request-ID response header. The ID is taken from the incoming
+// X-Request-ID, then from the trace-id of a W3C traceparent, and generated
+// when neither carries a usable value.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get(HeaderRequestID)
+		if !validRequestID(reqID) {
+			reqID = traceID(r.Header.Get(headerTraceparent))
+		}
+
+		if reqID == "" {
+			reqID = uuid.New().String()
+		}
+
+		w.Header().Set(HeaderRequestID, reqID)
+
+		next.ServeHTTP(w, r.WithContext(logctx.WithReqID(r.Context(), reqID)))
+	})
+}
+
+// validRequestID rejects values that would break log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// traceID extracts the trace-id from a traceparent header
+// ("version-traceid-parentid-flags"), returning "" when it is malformed.
+func traceID(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+
+	allZero := true
+
+	for _, c := range parts[1] {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f':
+		default:
+			return ""
+		}
+
+		if c != '0' {
+			allZero = false
+		}
+	}
+
+	if allZero {
+		return ""
+	}
+
+	return parts[1]
+}
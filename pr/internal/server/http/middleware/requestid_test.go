@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "uuid", id: "3f2b8c1e-6d4a-4b9e-9f1a-0c2d3e4f5a6b", want: true},
+		{name: "printable ascii", id: "req_42:a/b~", want: true},
+		{name: "max length", id: strings.Repeat("a", maxRequestIDLen), want: true},
+		{name: "empty", id: "", want: false},
+		{name: "too long", id: strings.Repeat("a", maxRequestIDLen+1), want: false},
+		{name: "space", id: "req 42", want: false},
+		{name: "newline", id: "req\n42", want: false},
+		{name: "control", id: "req\x0042", want: false},
+		{name: "delete", id: "req\x7f", want: false},
+		{name: "non-ascii", id: "запрос", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validRequestID(tt.id); got != tt.want {
+				t.Errorf("validRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	const id = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+		want        string
+	}{
+		{name: "valid", traceparent: "00-" + id + "-00f067aa0ba902b7-01", want: id},
+		{name: "surrounding spaces", traceparent: " 00-" + id + "-00f067aa0ba902b7-01 ", want: id},
+		{name: "empty", traceparent: "", want: ""},
+		{name: "too few parts", traceparent: "00-" + id + "-01", want: ""},
+		{name: "too many parts", traceparent: "00-" + id + "-00f067aa0ba902b7-01-ff", want: ""},
+		{name: "short trace id", traceparent: "00-4bf92f35-00f067aa0ba902b7-01", want: ""},
+		{name: "upper case", traceparent: "00-" + strings.ToUpper(id) + "-00f067aa0ba902b7-01", want: ""},
+		{name: "not hex", traceparent: "00-" + strings.Repeat("z", 32) + "-00f067aa0ba902b7-01", want: ""},
+		{name: "all zero", traceparent: "00-" + strings.Repeat("0", 32) + "-00f067aa0ba902b7-01", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := traceID(tt.traceparent); got != tt.want {
+				t.Errorf("traceID(%q) = %q, want %q", tt.traceparent, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,13 +11,15 @@ import (
 	"pr/internal/dto"
 	"pr/internal/logctx"
 	"pr/internal/repo"
+	"pr/internal/repo/page"
 	"pr/internal/repo/pr"
 	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
 )
 
 func CreatePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 		log.InfoContext(ctx, "Received CreatePR request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
@@ -26,14 +28,14 @@ func CreatePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		var body dto.CreatePRRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			log.WarnContext(ctx, "Invalid JSON in CreatePR request", slog.Any("error", err))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		if body.ID == "" || body.Name == "" || body.AuthorID == "" {
-			log.WarnContext(ctx, "Missing required fields in CreatePR request")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		if err := body.Validate(); err != nil {
+			log.WarnContext(ctx, "Invalid CreatePR request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
 
 			return
 		}
@@ -42,19 +44,21 @@ func CreatePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		ctx = logctx.WithUserID(ctx, body.AuthorID)
 
 		prModel := pr.PRModel{
-			ID:       body.ID,
-			Name:     body.Name,
-			AuthorID: body.AuthorID,
-			Status:   "OPEN",
+			ID:           body.ID,
+			Name:         body.Name,
+			AuthorID:     body.AuthorID,
+			Status:       "OPEN",
+			ChangedFiles: body.ChangedFiles,
+			Labels:       body.Labels,
 		}
 
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		reviewers, err := repos.PR.CreatePR(ctx, prModel)
+		created, assigned, err := repos.PR.CreatePR(ctx, prModel)
 		if err != nil {
 			log.ErrorContext(ctx, "Failed to create PR", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+			errorhandler.WriteError(ctx, w, err, log)
 
 			return
 		}
@@ -66,25 +70,32 @@ func CreatePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 					Name:     body.Name,
 					AuthorID: body.AuthorID,
 					Status:   "OPEN",
+					Labels:   body.Labels,
 				},
-				AssignedReviewers: reviewers,
+				AssignedReviewers: assigned.Reviewers,
 			},
+			FallbackReviewers:  assigned.FallbackReviewers,
+			CodeOwnerReviewers: assigned.CodeOwners,
+			SkillReviewers:     assigned.SkillReviewers,
+			Warnings:           dto.AssignWarnings(assigned.CapacityExceeded),
+			UnfilledSlots:      assigned.UnfilledSlots,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		etag.Set(w, created.Version)
 		w.WriteHeader(http.StatusCreated)
 
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			log.ErrorContext(ctx, "Failed to encode CreatePR response", slog.Any("error", err))
 		}
 
-		log.InfoContext(ctx, "PR created successfully")
+		log.InfoContext(ctx, "PR created successfully", slog.Int("unfilled_slots", assigned.UnfilledSlots))
 	}
 }
 
-func MergePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+func MergePR(repos *repo.Repo, log *slog.Logger, allowOverride bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 		log.InfoContext(ctx, "Received MergePR request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
@@ -93,58 +104,79 @@ func MergePR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		var body dto.MergePRRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			log.WarnContext(ctx, "Invalid JSON in MergePR request", slog.Any("error", err))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		if body.ID == "" {
-			log.WarnContext(ctx, "Missing pull_request_id in MergePR request")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		mergePR(ctx, w, r, repos, log, body, allowOverride)
+	}
+}
 
-			return
-		}
+func mergePR(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo, log *slog.Logger,
+	body dto.MergePRRequest, allowOverride bool) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid MergePR request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		ctx = logctx.WithPR(ctx, body.ID)
+		return
+	}
 
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+	if body.Override && !allowOverride {
+		log.WarnContext(ctx, "Merge policy override is disabled")
+		errorhandler.WriteError(ctx, w, apperrors.ErrMergeOverrideDisabled, log)
 
-		prmodel, reviewers, err := repos.PR.MergePR(ctx, body.ID)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to merge PR", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+		return
+	}
 
-			return
-		}
+	ctx = logctx.WithPR(ctx, body.ID)
 
-		response := dto.MergePRResponse{
-			PR: dto.PRWithReviewersAndMerge{
-				PR: dto.PR{
-					ID:       prmodel.ID,
-					Name:     prmodel.Name,
-					AuthorID: prmodel.AuthorID,
-					Status:   prmodel.Status,
-				},
-				AssignedReviewers: reviewers,
-				MergedAt:          prmodel.MergedAt,
-			},
-		}
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in MergePR request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		return
+	}
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode MergePR response", slog.Any("error", err))
-		}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-		log.InfoContext(ctx, "PR merged successfully")
+	prmodel, merged, err := repos.PR.MergePR(ctx, body.ID, body.Override, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to merge PR", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
 	}
+
+	response := dto.MergePRResponse{
+		PR: dto.PRWithReviewersAndMerge{
+			PR: dto.PR{
+				ID:       prmodel.ID,
+				Name:     prmodel.Name,
+				AuthorID: prmodel.AuthorID,
+				Status:   prmodel.Status,
+			},
+			AssignedReviewers: merged.Reviewers,
+			MergedAt:          prmodel.MergedAt,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, prmodel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode MergePR response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "PR merged successfully")
 }
 
 func SwapPRReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 		log.InfoContext(ctx, "Received SwapPRReviewer request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
@@ -153,55 +185,246 @@ func SwapPRReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		var body dto.SwapPRReviewerRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			log.WarnContext(ctx, "Invalid JSON in SwapPRReviewer request", slog.Any("error", err))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		if body.PRID == "" || body.OldUserID == "" {
-			log.WarnContext(ctx, "Missing required fields in SwapPRReviewer request",
-				slog.String("pr_id", body.PRID),
-				slog.String("old_user_id", body.OldUserID))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		swapPRReviewer(ctx, w, r, repos, log, body)
+	}
+}
+
+func swapPRReviewer(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo, log *slog.Logger,
+	body dto.SwapPRReviewerRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SwapPRReviewer request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithPR(ctx, body.PRID)
+	ctx = logctx.WithUserID(ctx, body.OldUserID)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in SwapPRReviewer request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prm, assigned, err := repos.PR.SwapPRReviewer(ctx, pr.SwapRequest{
+		PRID:          body.PRID,
+		OldReviewerID: body.OldUserID,
+		NewReviewerID: body.NewReviewerID,
+		Exclude:       body.Exclude,
+		Reason:        body.Reason,
+	}, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to swap PR reviewer", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	response := dto.SwapPRReviewerResponse{
+		PR: dto.PRWithReviewers{
+			PR: dto.PR{
+				ID:       prm.ID,
+				Name:     prm.Name,
+				Status:   prm.Status,
+				AuthorID: prm.AuthorID,
+			},
+			AssignedReviewers: assigned.Reviewers,
+		},
+		ReplacedBy:        assigned.ReplacedBy,
+		FallbackReviewers: assigned.FallbackReviewers,
+		Warnings:          dto.AssignWarnings(assigned.CapacityExceeded),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, prm.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode SwapPRReviewer response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "PR reviewer swapped successfully",
+		slog.String("new_reviewer_id", assigned.ReplacedBy))
+}
+
+func GetPR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetPR request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getPR(ctx, w, repos, log, dto.GetPRRequest{ID: r.URL.Query().Get("pull_request_id")})
+	}
+}
+
+func getPR(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetPRRequest) {
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetPR request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithPR(ctx, req.ID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prm, reviewers, err := repos.PR.GetPR(ctx, req.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get PR", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	historyModel, err := repos.PR.GetPRHistory(ctx, req.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get PR history", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	reviewModel, err := repos.PR.GetPRReviews(ctx, req.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get PR reviews", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	reviews := make([]dto.PRReview, 0, len(reviewModel))
+	for _, rv := range reviewModel {
+		reviews = append(reviews, dto.PRReview{
+			UpdatedAt: rv.UpdatedAt,
+			UserID:    rv.UserID,
+			State:     rv.State,
+		})
+	}
+
+	history := make([]dto.PRHistoryEntry, 0, len(historyModel))
+	for _, h := range historyModel {
+		history = append(history, dto.PRHistoryEntry{
+			CreatedAt: h.CreatedAt,
+			Event:     h.Event,
+			UserID:    h.UserID,
+			OldUserID: h.OldUserID,
+			Reason:    h.Reason,
+		})
+	}
+
+	response := dto.GetPRResponse{
+		PR: dto.PRDetails{
+			PR: dto.PR{
+				ID:       prm.ID,
+				Name:     prm.Name,
+				AuthorID: prm.AuthorID,
+				Status:   prm.Status,
+				Labels:   prm.Labels,
+			},
+			AssignedReviewers: reviewers,
+			CreatedAt:         prm.CreatedAt,
+			MergedAt:          prm.MergedAt,
+			Reviews:           reviews,
+			History:           history,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, prm.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode GetPR response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "PR retrieved successfully")
+}
+
+// ListPRs serves both /pullRequest/list and GET /api/v1/pull-requests;
+// all filters come from the query string.
+func ListPRs(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received ListPRs request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		req, err := dto.ParseListPRsRequest(r.URL.Query(), time.Now())
+		if err != nil {
+			log.WarnContext(ctx, "Invalid ListPRs request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
 
 			return
 		}
 
-		ctx = logctx.WithPR(ctx, body.PRID)
-		ctx = logctx.WithUserID(ctx, body.OldUserID)
-
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		prm, reviewers, reviewer, err := repos.PR.SwapPRReviewer(ctx, body.PRID, body.OldUserID)
+		prsModel, info, err := repos.PR.ListPRs(ctx, pr.ListFilter{
+			CreatedAfter:  req.CreatedAfter,
+			CreatedBefore: req.CreatedBefore,
+			TeamName:      req.TeamName,
+			AuthorID:      req.AuthorID,
+			ReviewerID:    req.ReviewerID,
+			Status:        req.Status,
+			NameQuery:     req.Query,
+		}, page.Request{Cursor: req.Cursor, Limit: req.Limit, Desc: req.Desc})
 		if err != nil {
-			log.ErrorContext(ctx, "Failed to swap PR reviewer", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+			log.ErrorContext(ctx, "Failed to list PRs", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
 
 			return
 		}
 
-		response := dto.SwapPRReviewerResponse{
-			PR: dto.PRWithReviewers{
+		prs := make([]dto.PRDetails, 0, len(prsModel))
+		for _, val := range prsModel {
+			prs = append(prs, dto.PRDetails{
 				PR: dto.PR{
-					ID:       prm.ID,
-					Name:     prm.Name,
-					Status:   prm.Status,
-					AuthorID: prm.AuthorID,
+					ID:       val.ID,
+					Name:     val.Name,
+					AuthorID: val.AuthorID,
+					Status:   val.Status,
+					Labels:   val.Labels,
 				},
-				AssignedReviewers: reviewers,
+				AssignedReviewers: val.Reviewers,
+				CreatedAt:         val.CreatedAt,
+				MergedAt:          val.MergedAt,
+			})
+		}
+
+		response := dto.ListPRsResponse{
+			PullRequests: prs,
+			Pagination: dto.Pagination{
+				NextCursor: info.NextCursor,
+				Limit:      info.Limit,
+				HasMore:    info.HasMore,
 			},
-			ReplacedBy: reviewer,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode SwapPRReviewer response", slog.Any("error", err))
+			log.ErrorContext(ctx, "Failed to encode ListPRs response", slog.Any("error", err))
 		}
 
-		log.InfoContext(ctx, "PR reviewer swapped successfully",
-			slog.String("new_reviewer_id", reviewer))
+		log.InfoContext(ctx, "PRs listed successfully")
 	}
 }
@@ -0,0 +1,35 @@
+package pr
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pr/internal/dto"
+	"pr/internal/repo"
+	"strings"
+	"testing"
+)
+
+func TestMergeOverrideDisabled(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// the override is refused before the repo is touched, so none is needed
+	h := MergePR(&repo.Repo{}, log, false)
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge",
+		strings.NewReader(`{"pull_request_id":"pr-1","override":true}`))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response %q: %v", rec.Body.String(), err)
+	}
+
+	if rec.Code != http.StatusForbidden || resp.Error.Code != "MERGE_OVERRIDE_DISABLED" {
+		t.Errorf("merge with override = %d %s, want 403 MERGE_OVERRIDE_DISABLED", rec.Code, resp.Error.Code)
+	}
+}
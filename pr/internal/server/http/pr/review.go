@@ -0,0 +1,104 @@
+package pr
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/pr"
+	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
+)
+
+func SetReviewState(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetReviewState request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetReviewStateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetReviewState request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		setReviewState(ctx, w, r, repos, log, body)
+	}
+}
+
+func setReviewState(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo, log *slog.Logger,
+	body dto.SetReviewStateRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetReviewState request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithPR(ctx, body.PRID)
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in SetReviewState request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prm, res, err := repos.PR.SetReviewState(ctx, pr.ReviewStateRequest{
+		PRID:   body.PRID,
+		UserID: body.UserID,
+		State:  body.State,
+		Reason: body.Reason,
+	}, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to set review state", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	if res.Reviewers == nil {
+		res.Reviewers = []string{}
+	}
+
+	response := dto.SetReviewStateResponse{
+		PR: dto.PRWithReviewers{
+			PR: dto.PR{
+				ID:       prm.ID,
+				Name:     prm.Name,
+				Status:   prm.Status,
+				AuthorID: prm.AuthorID,
+			},
+			AssignedReviewers: res.Reviewers,
+		},
+		ReplacedBy:        res.ReplacedBy,
+		FallbackReviewers: res.FallbackReviewers,
+		Warnings:          dto.AssignWarnings(res.CapacityExceeded),
+		UnfilledSlots:     res.UnfilledSlots,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, prm.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode SetReviewState response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Review state changed successfully", slog.String("state", body.State))
+}
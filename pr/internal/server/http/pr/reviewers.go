@@ -0,0 +1,118 @@
+package pr
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/pr"
+	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
+)
+
+func AddReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddReviewer request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.ChangeReviewerRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddReviewer request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		changeReviewer(ctx, w, r, repos, log, body, "AddReviewer", repos.PR.AddPRReviewer)
+	}
+}
+
+func RemoveReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RemoveReviewer request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.ChangeReviewerRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in RemoveReviewer request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		changeReviewer(ctx, w, r, repos, log, body, "RemoveReviewer", repos.PR.RemovePRReviewer)
+	}
+}
+
+// changeReviewer applies a manual reviewer change (op names it in logs)
+// and writes the PR with its updated reviewers.
+func changeReviewer(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.ChangeReviewerRequest, op string,
+	change func(ctx context.Context, prID, userID string, expectedVersion *int64) (pr.PRModel, []string, error)) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid "+op+" request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithPR(ctx, body.PRID)
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in "+op+" request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	prm, reviewers, err := change(ctx, body.PRID, body.UserID, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to change PR reviewers", slog.String("op", op), slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+
+	response := dto.ChangeReviewerResponse{
+		PR: dto.PRWithReviewers{
+			PR: dto.PR{
+				ID:       prm.ID,
+				Name:     prm.Name,
+				Status:   prm.Status,
+				AuthorID: prm.AuthorID,
+			},
+			AssignedReviewers: reviewers,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, prm.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode "+op+" response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "PR reviewers changed successfully", slog.String("op", op))
+}
@@ -0,0 +1,141 @@
+package pr
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/repo"
+	errorhandler "pr/internal/server/http/error"
+
+	"github.com/go-chi/chi"
+)
+
+// GET /api/v1/pull-requests/{id}
+func GetPRByID(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetPR request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getPR(ctx, w, repos, log, dto.GetPRRequest{ID: chi.URLParam(r, "id")})
+	}
+}
+
+// POST /api/v1/pull-requests/{id}/merge
+func MergePRByID(repos *repo.Repo, log *slog.Logger, allowOverride bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received MergePR request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		// the body is optional and only carries the override flag
+		var body dto.MergePRRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			log.WarnContext(ctx, "Invalid JSON in MergePR request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.ID = chi.URLParam(r, "id")
+
+		mergePR(ctx, w, r, repos, log, body, allowOverride)
+	}
+}
+
+// POST /api/v1/pull-requests/{id}/reviewers/{uid}:reassign
+func ReassignReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SwapPRReviewer request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		// the body is optional: without it a random reviewer is picked
+		var body dto.SwapPRReviewerRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			log.WarnContext(ctx, "Invalid JSON in SwapPRReviewer request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.PRID = chi.URLParam(r, "id")
+		body.OldUserID = chi.URLParam(r, "uid")
+
+		swapPRReviewer(ctx, w, r, repos, log, body)
+	}
+}
+
+// POST /api/v1/pull-requests/{id}/reviewers
+func PostPRReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddReviewer request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.ChangeReviewerRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddReviewer request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.PRID = chi.URLParam(r, "id")
+
+		changeReviewer(ctx, w, r, repos, log, body, "AddReviewer", repos.PR.AddPRReviewer)
+	}
+}
+
+// DELETE /api/v1/pull-requests/{id}/reviewers/{uid}
+func DeletePRReviewer(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RemoveReviewer request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		changeReviewer(ctx, w, r, repos, log, dto.ChangeReviewerRequest{
+			PRID:   chi.URLParam(r, "id"),
+			UserID: chi.URLParam(r, "uid"),
+		}, "RemoveReviewer", repos.PR.RemovePRReviewer)
+	}
+}
+
+// PUT /api/v1/pull-requests/{id}/reviewers/{uid}/state
+func PutReviewState(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetReviewState request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetReviewStateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetReviewState request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.PRID = chi.URLParam(r, "id")
+		body.UserID = chi.URLParam(r, "uid")
+
+		setReviewState(ctx, w, r, repos, log, body)
+	}
+}
@@ -1,14 +1,21 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"pr/internal/config"
 	"pr/internal/repo"
+	"pr/internal/server/http/health"
+	"pr/internal/server/http/middleware"
 	"pr/internal/server/http/pr"
+	"pr/internal/server/http/tag"
 	"pr/internal/server/http/team"
 	"pr/internal/server/http/user"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -19,25 +26,81 @@ type Server struct {
 	cfg  *config.HTTP
 	repo *repo.Repo
 
+	errCh chan error
+	ready atomic.Bool
+
 	// Do not touch
 	srv *http.Server
 }
 
-func (s *Server) CreateServer() {
+// allowMergeOverride reports whether merges may bypass the team merge
+// policy; it is off unless configured.
+func (s *Server) allowMergeOverride() bool {
+	allow, _ := strconv.ParseBool(s.cfg.AllowMergeOverride)
+	return allow
+}
+
+// CreateServer binds the listening socket and starts serving in the background.
+// Bind failures (e.g. the port is already in use) are returned right away,
+// later serve failures are reported through Errors.
+func (s *Server) CreateServer() error {
+	idempotencyTTL, _ := strconv.Atoi(s.cfg.IdempotencyTTL)
+	timeout, _ := strconv.Atoi(s.cfg.Timeout)
+
 	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Idempotency(s.repo.Idempotency,
+		time.Duration(idempotencyTTL)*time.Second, time.Duration(timeout)*time.Second, s.log))
+
+	router.Get("/health/live", health.Live())
+	router.Get("/health/ready", health.Ready(&s.ready))
 
+	// legacy RPC-style routes, kept as aliases of the /api/v1 handlers
 	router.Post("/team/add", team.CreateTeam(s.repo, s.log))
 	router.Get("/team/get", team.GetTeam(s.repo, s.log))
+	router.Post("/team/addMembers", team.AddMembers(s.repo, s.log))
+	router.Post("/team/removeMembers", team.RemoveMembers(s.repo, s.log))
+	router.Post("/team/rename", team.RenameTeam(s.repo, s.log))
+	router.Post("/team/delete", team.DeleteTeam(s.repo, s.log))
+	router.Get("/team/getSettings", team.GetSettings(s.repo, s.log))
+	router.Post("/team/setSettings", team.SetSettings(s.repo, s.log))
+	router.Get("/team/getCodeOwners", team.GetCodeOwners(s.repo, s.log))
+	router.Post("/team/setCodeOwners", team.SetCodeOwners(s.repo, s.log))
 	router.Post("/users/setIsActive", user.SetUserFlag(s.repo, s.log))
 	router.Post("/pullRequest/create", pr.CreatePR(s.repo, s.log))
-	router.Post("/pullRequest/merge", pr.MergePR(s.repo, s.log))
+	router.Post("/pullRequest/merge", pr.MergePR(s.repo, s.log, s.allowMergeOverride()))
 	router.Post("/pullRequest/reassign", pr.SwapPRReviewer(s.repo, s.log))
+	router.Post("/pullRequest/reviewers/add", pr.AddReviewer(s.repo, s.log))
+	router.Post("/pullRequest/reviewers/remove", pr.RemoveReviewer(s.repo, s.log))
+	router.Post("/pullRequest/reviewers/setState", pr.SetReviewState(s.repo, s.log))
+	router.Get("/pullRequest/get", pr.GetPR(s.repo, s.log))
+	router.Get("/pullRequest/list", pr.ListPRs(s.repo, s.log))
 	router.Get("/users/getReview", user.GetUserPR(s.repo, s.log))
+	router.Post("/users/moveTeam", user.MoveUserTeam(s.repo, s.log))
+	router.Get("/users/getHistory", user.GetUserHistory(s.repo, s.log))
+	router.Post("/users/delete", user.DeleteUser(s.repo, s.log))
+	router.Post("/users/restore", user.RestoreUser(s.repo, s.log))
+	router.Post("/users/setSettings", user.SetUserSettings(s.repo, s.log))
+	router.Get("/users/getNotifications", user.GetNotificationSettings(s.repo, s.log))
+	router.Post("/users/setNotifications", user.SetNotificationSettings(s.repo, s.log))
+	router.Get("/users/getDigest", user.GetDigest(s.repo, s.log))
+	router.Post("/users/addUnavailability", user.AddUnavailability(s.repo, s.log))
+	router.Get("/users/getUnavailability", user.GetUnavailability(s.repo, s.log))
+	router.Post("/users/updateUnavailability", user.UpdateUnavailability(s.repo, s.log))
+	router.Post("/users/deleteUnavailability", user.DeleteUnavailability(s.repo, s.log))
+	router.Post("/tags/create", tag.CreateTag(s.repo, s.log))
+	router.Get("/tags/list", tag.ListTags(s.repo, s.log))
+	router.Get("/tags/get", tag.GetTag(s.repo, s.log))
+	router.Post("/tags/update", tag.UpdateTag(s.repo, s.log))
+	router.Post("/tags/delete", tag.DeleteTag(s.repo, s.log))
+
+	router.Route("/api/v1", s.routesV1)
 
 	corsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, X-Request-ID, traceparent, Idempotency-Key, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Idempotent-Replayed, ETag")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -48,7 +111,6 @@ func (s *Server) CreateServer() {
 	})
 
 	idleTimeout, _ := strconv.Atoi(s.cfg.IdleTimeout)
-	timeout, _ := strconv.Atoi(s.cfg.Timeout)
 	srv := &http.Server{
 		Addr:         s.cfg.Addr,
 		Handler:      corsHandler,
@@ -57,26 +119,103 @@ func (s *Server) CreateServer() {
 		IdleTimeout:  time.Duration(idleTimeout) * time.Second,
 	}
 
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.srv = srv
+
 	go func() {
 		s.log.Info("HTTP server starting", slog.String("addr", s.cfg.Addr))
 
-		if err := srv.ListenAndServe(); err != nil {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			s.log.Error("HTTP server failed", slog.Any("error", err))
-			return
+			s.errCh <- err
 		}
 	}()
 
-	s.srv = srv
+	s.ready.Store(true)
+
+	return nil
+}
+
+func (s *Server) routesV1(r chi.Router) {
+	r.Get("/teams/{name}", team.GetTeamByName(s.repo, s.log))
+	r.Put("/teams/{name}", team.PutTeam(s.repo, s.log))
+	r.Patch("/teams/{name}", team.PatchTeam(s.repo, s.log))
+	r.Delete("/teams/{name}", team.DeleteTeamByName(s.repo, s.log))
+	r.Get("/teams/{name}/settings", team.GetTeamSettings(s.repo, s.log))
+	r.Put("/teams/{name}/settings", team.PutTeamSettings(s.repo, s.log))
+	r.Get("/teams/{name}/code-owners", team.GetTeamCodeOwners(s.repo, s.log))
+	r.Put("/teams/{name}/code-owners", team.PutTeamCodeOwners(s.repo, s.log))
+	r.Get("/teams/{name}/members", team.GetTeamMembers(s.repo, s.log))
+	r.Post("/teams/{name}/members", team.AddTeamMembers(s.repo, s.log))
+	r.Delete("/teams/{name}/members/{uid}", team.RemoveTeamMember(s.repo, s.log))
+
+	r.Get("/users/{id}", user.GetUser(s.repo, s.log))
+	r.Put("/users/{id}", user.PutUser(s.repo, s.log))
+	r.Delete("/users/{id}", user.DeleteUserByID(s.repo, s.log))
+	r.Post("/users/{id}/restore", user.RestoreUserByID(s.repo, s.log))
+	r.Get("/users/{id}/reviews", user.GetUserReviews(s.repo, s.log))
+	r.Put("/users/{id}/team", user.PutUserTeam(s.repo, s.log))
+	r.Put("/users/{id}/settings", user.PutUserSettings(s.repo, s.log))
+	r.Get("/users/{id}/notifications", user.GetUserNotifications(s.repo, s.log))
+	r.Put("/users/{id}/notifications", user.PutUserNotifications(s.repo, s.log))
+	r.Get("/users/{id}/digest", user.GetUserDigest(s.repo, s.log))
+	r.Get("/users/{id}/history", user.GetUserHistoryByID(s.repo, s.log))
+	r.Get("/users/{id}/unavailability", user.GetUserUnavailability(s.repo, s.log))
+	r.Post("/users/{id}/unavailability", user.PostUserUnavailability(s.repo, s.log))
+	r.Put("/users/{id}/unavailability/{wid}", user.PutUserUnavailability(s.repo, s.log))
+	r.Delete("/users/{id}/unavailability/{wid}", user.DeleteUserUnavailability(s.repo, s.log))
+
+	r.Post("/pull-requests", pr.CreatePR(s.repo, s.log))
+	r.Get("/pull-requests", pr.ListPRs(s.repo, s.log))
+	r.Get("/pull-requests/{id}", pr.GetPRByID(s.repo, s.log))
+	r.Post("/pull-requests/{id}/merge", pr.MergePRByID(s.repo, s.log, s.allowMergeOverride()))
+	r.Post("/pull-requests/{id}/reviewers/{uid}:reassign", pr.ReassignReviewer(s.repo, s.log))
+	r.Post("/pull-requests/{id}/reviewers", pr.PostPRReviewer(s.repo, s.log))
+	r.Delete("/pull-requests/{id}/reviewers/{uid}", pr.DeletePRReviewer(s.repo, s.log))
+	r.Put("/pull-requests/{id}/reviewers/{uid}/state", pr.PutReviewState(s.repo, s.log))
+
+	r.Get("/tags", tag.ListTags(s.repo, s.log))
+	r.Post("/tags", tag.CreateTag(s.repo, s.log))
+	r.Get("/tags/{name}", tag.GetTagByName(s.repo, s.log))
+	r.Put("/tags/{name}", tag.PutTag(s.repo, s.log))
+	r.Delete("/tags/{name}", tag.DeleteTagByName(s.repo, s.log))
+}
+
+// Errors delivers the error that made the server stop serving on its own.
+func (s *Server) Errors() <-chan error {
+	return s.errCh
 }
 
-func (s *Server) Close() error {
-	return s.srv.Close()
+// Shutdown marks the instance as not ready and keeps serving for delay, so
+// load balancers polling /health/ready stop routing to it, then stops
+// accepting connections and waits for in-flight requests until ctx
+// expires. Connections still active at that point are closed forcibly.
+func (s *Server) Shutdown(ctx context.Context, delay time.Duration) error {
+	s.ready.Store(false)
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+
+		return err
+	}
+
+	return nil
 }
 
 func NewRestAPI(cfg *config.HTTP, repo *repo.Repo, log *slog.Logger) *Server {
 	return &Server{
-		cfg:  cfg,
-		repo: repo,
-		log:  log,
+		cfg:   cfg,
+		repo:  repo,
+		log:   log,
+		errCh: make(chan error, 1),
 	}
 }
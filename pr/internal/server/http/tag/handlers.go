@@ -0,0 +1,255 @@
+package tag
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/repo"
+	"pr/internal/repo/tag"
+	errorhandler "pr/internal/server/http/error"
+	"time"
+)
+
+func CreateTag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received CreateTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.CreateTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in CreateTag request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		createTag(ctx, w, repos, log, body)
+	}
+}
+
+func createTag(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.CreateTagRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid CreateTag request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t, err := repos.Tag.CreateTag(ctx, tag.TagModel{
+		Name:        body.Name,
+		Description: body.Description,
+		Mode:        modeOrDefault(body.Mode),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to create tag", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeTag(ctx, w, log, http.StatusCreated, t)
+
+	log.InfoContext(ctx, "Tag created successfully", slog.String("tag", t.Name))
+}
+
+func ListTags(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received ListTags request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		tagsModel, err := repos.Tag.ListTags(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to list tags", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
+
+			return
+		}
+
+		tags := make([]dto.Tag, 0, len(tagsModel))
+		for _, val := range tagsModel {
+			tags = append(tags, toTagDTO(val))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(dto.ListTagsResponse{Tags: tags}); err != nil {
+			log.ErrorContext(ctx, "Failed to encode ListTags response", slog.Any("error", err))
+		}
+
+		log.InfoContext(ctx, "Tags listed successfully", slog.Int("tags", len(tags)))
+	}
+}
+
+func GetTag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getTag(ctx, w, repos, log, dto.GetTagRequest{Name: r.URL.Query().Get("name")})
+	}
+}
+
+func getTag(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetTagRequest) {
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetTag request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t, err := repos.Tag.GetTag(ctx, req.Name)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get tag", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeTag(ctx, w, log, http.StatusOK, t)
+
+	log.InfoContext(ctx, "Tag retrieved successfully", slog.String("tag", t.Name))
+}
+
+func UpdateTag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received UpdateTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.UpdateTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in UpdateTag request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		updateTag(ctx, w, repos, log, body)
+	}
+}
+
+func updateTag(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.UpdateTagRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid UpdateTag request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t, err := repos.Tag.UpdateTag(ctx, tag.TagModel{
+		Name:        body.Name,
+		Description: body.Description,
+		Mode:        modeOrDefault(body.Mode),
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to update tag", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeTag(ctx, w, log, http.StatusOK, t)
+
+	log.InfoContext(ctx, "Tag updated successfully", slog.String("tag", t.Name))
+}
+
+func DeleteTag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.DeleteTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in DeleteTag request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		deleteTag(ctx, w, repos, log, body)
+	}
+}
+
+func deleteTag(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.DeleteTagRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid DeleteTag request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := repos.Tag.DeleteTag(ctx, body.Name); err != nil {
+		log.ErrorContext(ctx, "Failed to delete tag", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(ctx, "Tag deleted successfully", slog.String("tag", body.Name))
+}
+
+// modeOrDefault maps a missing mode to prefer.
+func modeOrDefault(mode string) string {
+	if mode == "" {
+		return tag.ModePrefer
+	}
+
+	return mode
+}
+
+func writeTag(ctx context.Context, w http.ResponseWriter, log *slog.Logger, status int, t tag.TagModel) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(dto.TagResponse{Tag: toTagDTO(t)}); err != nil {
+		log.ErrorContext(ctx, "Failed to encode tag response", slog.Any("error", err))
+	}
+}
+
+func toTagDTO(m tag.TagModel) dto.Tag {
+	return dto.Tag{
+		CreatedAt:   &m.CreatedAt,
+		Name:        m.Name,
+		Description: m.Description,
+		Mode:        m.Mode,
+	}
+}
@@ -0,0 +1,62 @@
+package tag
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/repo"
+	errorhandler "pr/internal/server/http/error"
+
+	"github.com/go-chi/chi"
+)
+
+// GET /api/v1/tags/{name}
+func GetTagByName(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getTag(ctx, w, repos, log, dto.GetTagRequest{Name: chi.URLParam(r, "name")})
+	}
+}
+
+// PUT /api/v1/tags/{name}
+func PutTag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received UpdateTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.UpdateTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in UpdateTag request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.Name = chi.URLParam(r, "name")
+
+		updateTag(ctx, w, repos, log, body)
+	}
+}
+
+// DELETE /api/v1/tags/{name}
+func DeleteTagByName(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteTag request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		deleteTag(ctx, w, repos, log, dto.DeleteTagRequest{Name: chi.URLParam(r, "name")})
+	}
+}
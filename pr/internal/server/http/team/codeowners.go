@@ -0,0 +1,141 @@
+package team
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/team"
+	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
+)
+
+func GetCodeOwners(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetCodeOwners request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getCodeOwners(ctx, w, repos, log, dto.GetCodeOwnersRequest{TeamName: r.URL.Query().Get("team_name")})
+	}
+}
+
+func getCodeOwners(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.GetCodeOwnersRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetCodeOwners request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, rules, err := repos.Team.GetCodeOwners(ctx, body.TeamName)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get code owners", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeCodeOwners(ctx, w, log, teamModel, rules)
+
+	log.InfoContext(ctx, "Code owners retrieved successfully")
+}
+
+func SetCodeOwners(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetCodeOwners request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetCodeOwnersRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetCodeOwners request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		setCodeOwners(ctx, w, r, repos, log, body)
+	}
+}
+
+func setCodeOwners(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.SetCodeOwnersRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetCodeOwners request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in SetCodeOwners request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rules := make([]team.CodeOwnerRule, 0, len(body.Rules))
+	for _, rule := range body.Rules {
+		owners := rule.Owners
+		if owners == nil {
+			owners = []string{}
+		}
+
+		rules = append(rules, team.CodeOwnerRule{Pattern: rule.Pattern, Owners: owners})
+	}
+
+	teamModel, err := repos.Team.SetCodeOwners(ctx, body.TeamName, rules, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to set code owners", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeCodeOwners(ctx, w, log, teamModel, rules)
+
+	log.InfoContext(ctx, "Code owners updated successfully", slog.Int("rules", len(rules)))
+}
+
+func writeCodeOwners(ctx context.Context, w http.ResponseWriter, log *slog.Logger,
+	teamModel team.TeamModel, rules []team.CodeOwnerRule) {
+	response := dto.CodeOwnersResponse{
+		TeamName: teamModel.Name,
+		Rules:    make([]dto.CodeOwnerRule, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, dto.CodeOwnerRule{Pattern: rule.Pattern, Owners: rule.Owners})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode CodeOwners response", slog.Any("error", err))
+	}
+}
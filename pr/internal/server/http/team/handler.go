@@ -3,6 +3,7 @@ package team
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
@@ -11,13 +12,16 @@ import (
 	"pr/internal/dto"
 	"pr/internal/logctx"
 	"pr/internal/repo"
+	"pr/internal/repo/page"
+	"pr/internal/repo/team"
 	"pr/internal/repo/user"
 	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
 )
 
 func CreateTeam(repo *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 		log.InfoContext(ctx, "Received CreateTeam request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
@@ -26,132 +30,426 @@ func CreateTeam(repo *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		body := &dto.CreateTeamRequest{}
 		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
 			log.WarnContext(ctx, "Invalid JSON in CreateTeam request", slog.Any("error", err))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		if body.TeamName == "" {
-			log.WarnContext(ctx, "Missing team_name in CreateTeam request")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		expectedVersion, err := etag.IfMatch(r)
+		if err != nil {
+			log.WarnContext(ctx, "Invalid If-Match in CreateTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
 
 			return
 		}
 
-		ctx = logctx.WithTeam(ctx, body.TeamName)
+		saveTeam(ctx, w, repo, log, body, expectedVersion, false)
+	}
+}
 
-		userModelMem := make([]user.UserModel, 0, len(body.Members))
+// saveTeam creates the team when expectedVersion is nil and updates it
+// otherwise. With upsert an existing team is updated instead of reported
+// as TEAM_EXISTS.
+func saveTeam(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body *dto.CreateTeamRequest, expectedVersion *int64, upsert bool) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid CreateTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
 
-		for _, val := range body.Members {
-			if val.UserID == "" {
-				log.WarnContext(ctx, "Missing user_id in team member", slog.String("username", val.Username))
-				errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	userModelMem := make([]user.UserModel, 0, len(body.Members))
+
+	for _, val := range body.Members {
+		userModelMem = append(userModelMem, user.UserModel{
+			ID:             val.UserID,
+			TeamName:       body.TeamName,
+			IsActive:       val.IsActive,
+			UserName:       val.Username,
+			MaxOpenReviews: val.MaxOpenReviews,
+			Skills:         val.Skills,
+			Role:           val.Role,
+		})
+
+		ctx = logctx.WithUserID(ctx, val.UserID)
+	}
 
-				return
-			}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, users, err := repos.Team.CreateOrUpdateTeamWithUsers(ctx, body.TeamName, userModelMem, expectedVersion)
+	if upsert && expectedVersion == nil && errors.Is(err, apperrors.ErrTeamExists) {
+		anyVersion := repo.AnyVersion
+		expectedVersion = &anyVersion
+
+		teamModel, users, err = repos.Team.CreateOrUpdateTeamWithUsers(ctx, body.TeamName, userModelMem, expectedVersion)
+	}
+
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to create team", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	// new or reactivated members can take open review slots right away; the
+	// filler job retries anything left
+	if fills, err := repos.PR.FillOpenSlots(ctx, body.TeamName); err != nil {
+		log.WarnContext(ctx, "Failed to fill open review slots", slog.Any("error", err))
+	} else if len(fills) > 0 {
+		log.InfoContext(ctx, "Filled open review slots", slog.Int("pull_requests", len(fills)))
+	}
 
-			userModelMem = append(userModelMem, user.UserModel{
-				ID:       val.UserID,
-				TeamName: body.TeamName,
-				IsActive: val.IsActive,
-				UserName: val.Username,
-			})
+	var mem []dto.User
 
-			ctx = logctx.WithUserID(ctx, val.UserID)
+	for _, val := range users {
+		temp := dto.User{
+			UserID:         val.ID,
+			Username:       val.UserName,
+			IsActive:       val.IsActive,
+			MaxOpenReviews: val.MaxOpenReviews,
+			Skills:         val.Skills,
+			Role:           val.Role,
 		}
+		mem = append(mem, temp)
+	}
 
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+	response := &dto.CreateTeamResponse{
+		Team: dto.Team{
+			TeamName: body.TeamName,
+			Members:  mem,
+		},
+	}
 
-		_, users, err := repo.Team.CreateOrUpdateTeamWithUsers(ctx, body.TeamName, userModelMem)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to create team", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+	status := http.StatusCreated
+	if expectedVersion != nil {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode CreateTeam response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Team saved successfully", slog.Int("status", status))
+}
+
+func AddMembers(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddTeamMembers request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		body := &dto.CreateTeamRequest{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddTeamMembers request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		var mem []dto.User
+		addMembers(ctx, w, r, repos, log, body)
+	}
+}
 
-		for _, val := range users {
-			temp := dto.User{
-				UserID:   val.ID,
-				Username: val.UserName,
-				IsActive: val.IsActive,
-			}
-			mem = append(mem, temp)
-		}
+// addMembers upserts members into an existing team. Without If-Match any
+// version of the team is accepted.
+func addMembers(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body *dto.CreateTeamRequest) {
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in AddTeamMembers request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		response := &dto.CreateTeamResponse{
-			Team: dto.Team{
-				TeamName: body.TeamName,
-				Members:  mem,
-			},
-		}
+		return
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+	if expectedVersion == nil {
+		anyVersion := repo.AnyVersion
+		expectedVersion = &anyVersion
+	}
+
+	saveTeam(ctx, w, repos, log, body, expectedVersion, false)
+}
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode CreateTeam response", slog.Any("error", err))
+func RemoveMembers(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RemoveTeamMembers request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.RemoveTeamMembersRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in RemoveTeamMembers request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
 		}
 
-		log.InfoContext(ctx, "Team created successfully")
+		removeMembers(ctx, w, r, repos, log, body)
 	}
 }
 
-func GetTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+// removeMembers detaches users from the team and hands their open reviews
+// over to other members.
+func removeMembers(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.RemoveTeamMembersRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid RemoveTeamMembers request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in RemoveTeamMembers request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, reassignedModel, err := repos.Team.RemoveMembers(ctx, body.TeamName, body.UserIDs, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to remove team members", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	reassigned := make([]dto.ReassignedReview, 0, len(reassignedModel))
+	for _, val := range reassignedModel {
+		reassigned = append(reassigned, dto.ReassignedReview{
+			PRID:       val.PRID,
+			ReplacedBy: val.ReplacedBy,
+		})
+	}
+
+	response := dto.RemoveTeamMembersResponse{
+		TeamName:   teamModel.Name,
+		Removed:    body.UserIDs,
+		Reassigned: reassigned,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode RemoveTeamMembers response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Team members removed successfully", slog.Int("count", len(body.UserIDs)))
+}
+
+func RenameTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
-		log.InfoContext(ctx, "Received GetTeam request",
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RenameTeam request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 		)
 
-		teamName := r.URL.Query().Get("team_name")
-		if teamName == "" {
-			log.WarnContext(ctx, "Missing team_name query parameter")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		var body dto.RenameTeamRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in RenameTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		ctx = logctx.WithTeam(ctx, teamName)
+		renameTeam(ctx, w, r, repos, log, body)
+	}
+}
 
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+func renameTeam(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.RenameTeamRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid RenameTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		mems, err := repos.Team.GetUsersFromTeam(ctx, teamName)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to get team", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in RenameTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, err := repos.Team.RenameTeam(ctx, body.TeamName, body.NewTeamName, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to rename team", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	response := dto.RenameTeamResponse{
+		TeamName:         teamModel.Name,
+		PreviousTeamName: body.TeamName,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode RenameTeam response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Team renamed successfully", slog.String("new_team_name", teamModel.Name))
+}
+
+func DeleteTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.DeleteTeamRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in DeleteTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		var memResp []dto.User
-		for _, val := range mems {
-			memResp = append(memResp, dto.User{
-				UserID:   val.ID,
-				Username: val.UserName,
-				IsActive: val.IsActive,
-			})
-		}
+		deleteTeam(ctx, w, r, repos, log, body)
+	}
+}
 
-		response := dto.GetTeamResponse{
-			Team: dto.Team{
-				TeamName: teamName,
-				Members:  memResp,
-			},
-		}
+func deleteTeam(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.DeleteTeamRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid DeleteTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		return
+	}
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode GetTeam response", slog.Any("error", err))
-		}
+	ctx = logctx.WithTeam(ctx, body.TeamName)
 
-		log.InfoContext(ctx, "Team retrieved successfully")
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in DeleteTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := repos.Team.DeleteTeam(ctx, body.TeamName, expectedVersion); err != nil {
+		log.ErrorContext(ctx, "Failed to delete team", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(ctx, "Team deleted successfully")
+}
+
+func GetTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		query := r.URL.Query()
+		req, err := dto.ParseGetTeamRequest(query.Get("team_name"), query)
+
+		getTeam(ctx, w, repos, log, req, err, func(t dto.Team, p dto.Pagination) any {
+			return dto.GetTeamResponse{Team: t, Pagination: p}
+		})
+	}
+}
+
+// getTeam loads one page of team members and writes it in the shape
+// produced by render. parseErr is the result of parsing the request.
+func getTeam(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetTeamRequest, parseErr error, render func(dto.Team, dto.Pagination) any) {
+	if parseErr != nil {
+		log.WarnContext(ctx, "Invalid GetTeam request", slog.Any("error", parseErr))
+		errorhandler.WriteError(ctx, w, parseErr, log)
+
+		return
+	}
+
+	teamName := req.TeamName
+
+	ctx = logctx.WithTeam(ctx, teamName)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, mems, info, err := repos.Team.GetUsersFromTeam(ctx, teamName,
+		team.MemberFilter{IsActive: req.IsActive},
+		page.Request{Cursor: req.Cursor, Limit: req.Limit, Desc: req.Desc})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get team", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	var memResp []dto.User
+	for _, val := range mems {
+		memResp = append(memResp, dto.User{
+			UserID:         val.ID,
+			Username:       val.UserName,
+			IsActive:       val.IsActive,
+			MaxOpenReviews: val.MaxOpenReviews,
+			Skills:         val.Skills,
+			Role:           val.Role,
+		})
+	}
+
+	response := render(dto.Team{
+		TeamName: teamName,
+		Members:  memResp,
+	}, dto.Pagination{
+		NextCursor: info.NextCursor,
+		Limit:      info.Limit,
+		HasMore:    info.HasMore,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode GetTeam response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Team retrieved successfully")
 }
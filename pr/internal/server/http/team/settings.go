@@ -0,0 +1,180 @@
+package team
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/pr"
+	"pr/internal/repo/team"
+	"pr/internal/repo/user"
+	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
+)
+
+func GetSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTeamSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getSettings(ctx, w, repos, log, dto.GetTeamSettingsRequest{TeamName: r.URL.Query().Get("team_name")})
+	}
+}
+
+func getSettings(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.GetTeamSettingsRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetTeamSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	teamModel, settings, err := repos.Team.GetSettings(ctx, body.TeamName)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get team settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeSettings(ctx, w, log, teamModel, settings)
+
+	log.InfoContext(ctx, "Team settings retrieved successfully")
+}
+
+func SetSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetTeamSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetTeamSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetTeamSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		setSettings(ctx, w, r, repos, log, body)
+	}
+}
+
+func setSettings(ctx context.Context, w http.ResponseWriter, r *http.Request, repos *repo.Repo,
+	log *slog.Logger, body dto.SetTeamSettingsRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetTeamSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	expectedVersion, err := etag.IfMatch(r)
+	if err != nil {
+		log.WarnContext(ctx, "Invalid If-Match in SetTeamSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings := team.Settings{
+		FallbackTeams:  body.FallbackTeams,
+		ReviewerPolicy: body.ReviewerPolicy,
+		MergePolicy: team.MergePolicy{
+			MinApprovals:            body.MergePolicy.MinApprovals,
+			BlockOnChangesRequested: body.MergePolicy.BlockOnChangesRequested,
+		},
+		ReviewSLA: team.ReviewSLA{
+			RemindAfterHours:   body.ReviewSLA.RemindAfterHours,
+			EscalateAfterHours: body.ReviewSLA.EscalateAfterHours,
+			EscalationAction:   body.ReviewSLA.EscalationAction,
+			LeadID:             body.ReviewSLA.LeadID,
+		},
+		RoleRule: team.RoleRule{
+			MinReviewers: body.RoleRule.MinReviewers,
+			MinRole:      body.RoleRule.MinRole,
+		},
+	}
+	if settings.FallbackTeams == nil {
+		settings.FallbackTeams = []string{}
+	}
+
+	if settings.ReviewerPolicy == "" {
+		settings.ReviewerPolicy = pr.PolicyAllowPartial
+	}
+
+	if settings.ReviewSLA.EscalationAction == "" {
+		settings.ReviewSLA.EscalationAction = pr.EscalationReassign
+	}
+
+	if settings.RoleRule.MinRole == "" {
+		settings.RoleRule.MinRole = user.RoleSenior
+	}
+
+	teamModel, err := repos.Team.SetSettings(ctx, body.TeamName, settings, expectedVersion)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to set team settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeSettings(ctx, w, log, teamModel, settings)
+
+	log.InfoContext(ctx, "Team settings updated successfully")
+}
+
+func writeSettings(ctx context.Context, w http.ResponseWriter, log *slog.Logger,
+	teamModel team.TeamModel, settings team.Settings) {
+	response := dto.TeamSettingsResponse{
+		TeamName: teamModel.Name,
+		Settings: dto.TeamSettings{
+			FallbackTeams:  settings.FallbackTeams,
+			ReviewerPolicy: settings.ReviewerPolicy,
+			MergePolicy: dto.MergePolicy{
+				MinApprovals:            settings.MergePolicy.MinApprovals,
+				BlockOnChangesRequested: settings.MergePolicy.BlockOnChangesRequested,
+			},
+			ReviewSLA: dto.ReviewSLA{
+				RemindAfterHours:   settings.ReviewSLA.RemindAfterHours,
+				EscalateAfterHours: settings.ReviewSLA.EscalateAfterHours,
+				EscalationAction:   settings.ReviewSLA.EscalationAction,
+				LeadID:             settings.ReviewSLA.LeadID,
+			},
+			RoleRule: dto.RoleRule{
+				MinReviewers: settings.RoleRule.MinReviewers,
+				MinRole:      settings.RoleRule.MinRole,
+			},
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, teamModel.Version)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode TeamSettings response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,229 @@
+package team
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/repo"
+	errorhandler "pr/internal/server/http/error"
+	"pr/internal/server/http/etag"
+
+	"github.com/go-chi/chi"
+)
+
+// GET /api/v1/teams/{name}
+func GetTeamByName(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTeamByName request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		req, err := dto.ParseGetTeamRequest(chi.URLParam(r, "name"), r.URL.Query())
+
+		getTeam(ctx, w, repos, log, req, err, func(t dto.Team, p dto.Pagination) any {
+			return dto.GetTeamResponse{Team: t, Pagination: p}
+		})
+	}
+}
+
+// PUT /api/v1/teams/{name}: creates the team or, when it exists, upserts
+// the given members into it.
+func PutTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received PutTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		body := &dto.CreateTeamRequest{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in PutTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.TeamName = chi.URLParam(r, "name")
+
+		expectedVersion, err := etag.IfMatch(r)
+		if err != nil {
+			log.WarnContext(ctx, "Invalid If-Match in PutTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
+
+			return
+		}
+
+		saveTeam(ctx, w, repos, log, body, expectedVersion, true)
+	}
+}
+
+// PATCH /api/v1/teams/{name}: renames the team.
+func PatchTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RenameTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.PatchTeamRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in RenameTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		renameTeam(ctx, w, r, repos, log, dto.RenameTeamRequest{
+			TeamName:    chi.URLParam(r, "name"),
+			NewTeamName: body.TeamName,
+		})
+	}
+}
+
+// GET /api/v1/teams/{name}/settings
+func GetTeamSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTeamSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getSettings(ctx, w, repos, log, dto.GetTeamSettingsRequest{TeamName: chi.URLParam(r, "name")})
+	}
+}
+
+// PUT /api/v1/teams/{name}/settings
+func PutTeamSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetTeamSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetTeamSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetTeamSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.TeamName = chi.URLParam(r, "name")
+
+		setSettings(ctx, w, r, repos, log, body)
+	}
+}
+
+// GET /api/v1/teams/{name}/code-owners
+func GetTeamCodeOwners(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetCodeOwners request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getCodeOwners(ctx, w, repos, log, dto.GetCodeOwnersRequest{TeamName: chi.URLParam(r, "name")})
+	}
+}
+
+// PUT /api/v1/teams/{name}/code-owners
+func PutTeamCodeOwners(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetCodeOwners request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetCodeOwnersRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetCodeOwners request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.TeamName = chi.URLParam(r, "name")
+
+		setCodeOwners(ctx, w, r, repos, log, body)
+	}
+}
+
+// DELETE /api/v1/teams/{name}
+func DeleteTeamByName(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		deleteTeam(ctx, w, r, repos, log, dto.DeleteTeamRequest{TeamName: chi.URLParam(r, "name")})
+	}
+}
+
+// GET /api/v1/teams/{name}/members
+func GetTeamMembers(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetTeamMembers request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		req, err := dto.ParseGetTeamRequest(chi.URLParam(r, "name"), r.URL.Query())
+
+		getTeam(ctx, w, repos, log, req, err, func(t dto.Team, p dto.Pagination) any {
+			return dto.TeamMembersResponse{TeamName: t.TeamName, Members: t.Members, Pagination: p}
+		})
+	}
+}
+
+// POST /api/v1/teams/{name}/members: upserts members into an existing team.
+func AddTeamMembers(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddTeamMembers request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		body := &dto.CreateTeamRequest{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddTeamMembers request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.TeamName = chi.URLParam(r, "name")
+
+		addMembers(ctx, w, r, repos, log, body)
+	}
+}
+
+// DELETE /api/v1/teams/{name}/members/{uid}
+func RemoveTeamMember(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RemoveTeamMembers request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		removeMembers(ctx, w, r, repos, log, dto.RemoveTeamMembersRequest{
+			TeamName: chi.URLParam(r, "name"),
+			UserIDs:  []string{chi.URLParam(r, "uid")},
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/notify"
+	"pr/internal/repo"
+	errorhandler "pr/internal/server/http/error"
+	"time"
+)
+
+func GetDigest(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetDigest request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getDigest(ctx, w, repos, log, dto.GetDigestRequest{
+			UserID: r.URL.Query().Get("user_id"),
+		})
+	}
+}
+
+// getDigest renders the user's digest as the digest job would send it
+// now, whether or not the user has the digest enabled.
+func getDigest(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetDigestRequest) {
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetDigest request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, req.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings, err := repos.User.GetNotificationSettings(ctx, req.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get notification settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	loc, err := time.LoadLocation(settings.Digest.TimeZone)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load digest time zone", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	digest, err := notify.LoadDigest(ctx, repos.User, req.UserID, time.Now().In(loc))
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load digest", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	msg, err := notify.RenderDigest(digest)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to render digest", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	response := dto.DigestResponse{
+		GeneratedAt: digest.GeneratedAt,
+		UserID:      req.UserID,
+		TimeZone:    loc.String(),
+		Reviews:     make([]dto.DigestReview, 0, len(digest.Reviews)),
+		Subject:     msg.Subject,
+		Text:        msg.Text,
+		HTML:        msg.HTML,
+	}
+
+	for _, r := range digest.Reviews {
+		age := digest.GeneratedAt.Sub(r.CreatedAt)
+
+		response.Reviews = append(response.Reviews, dto.DigestReview{
+			CreatedAt:  r.CreatedAt,
+			PRID:       r.PRID,
+			PRName:     r.PRName,
+			AuthorID:   r.AuthorID,
+			Age:        notify.Age(age),
+			AgeMinutes: int64(age / time.Minute),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode Digest response", slog.Any("error", err))
+	}
+}
@@ -9,13 +9,15 @@ import (
 	"pr/internal/dto"
 	"pr/internal/logctx"
 	"pr/internal/repo"
+	"pr/internal/repo/page"
+	"pr/internal/repo/user"
 	errorhandler "pr/internal/server/http/error"
 	"time"
 )
 
 func SetUserFlag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 
 		log.InfoContext(ctx, "Received SetUserFlag request",
 			slog.String("method", r.Method),
@@ -25,102 +27,481 @@ func SetUserFlag(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 		var body dto.SetUserStatusRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			log.WarnContext(ctx, "Invalid JSON in request body", slog.Any("error", err))
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		if body.ID == "" {
-			log.WarnContext(ctx, "user_id is empty")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
-
-			return
+		// the legacy route has always read a missing is_active as false
+		if body.IsActive == nil {
+			body.IsActive = new(bool)
 		}
 
-		ctx = logctx.WithUserID(ctx, body.ID)
+		setUserStatus(ctx, w, repos, log, body)
+	}
+}
 
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+func setUserStatus(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.SetUserStatusRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetUserFlag request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		user, err := repos.User.SetUserStatus(ctx, body.ID, body.IsActive)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to update user status", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+		return
+	}
 
-			return
-		}
+	ctx = logctx.WithUserID(ctx, body.ID)
 
-		response := dto.SetUserStatusResponse{
-			User: dto.UserWithTeam{
-				UserID:   user.ID,
-				Username: user.UserName,
-				IsActive: user.IsActive,
-				TeamName: user.TeamName,
-			},
-		}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+	user, err := repos.User.SetUserStatus(ctx, body.ID, *body.IsActive)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to update user status", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode response", slog.Any("error", err))
+		return
+	}
+
+	// a reactivated member can take open review slots right away; the
+	// filler job retries anything left
+	if user.IsActive && user.TeamName != "" {
+		if fills, err := repos.PR.FillOpenSlots(ctx, user.TeamName); err != nil {
+			log.WarnContext(ctx, "Failed to fill open review slots", slog.Any("error", err))
+		} else if len(fills) > 0 {
+			log.InfoContext(ctx, "Filled open review slots", slog.Int("pull_requests", len(fills)))
 		}
+	}
 
-		log.InfoContext(ctx, "User status updated successfully")
+	response := dto.SetUserStatusResponse{
+		User: dto.UserWithTeam{
+			UserID:         user.ID,
+			Username:       user.UserName,
+			IsActive:       user.IsActive,
+			TeamName:       user.TeamName,
+			MaxOpenReviews: user.MaxOpenReviews,
+		},
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User status updated successfully")
 }
 
 func GetUserPR(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := logctx.WithReqID(r.Context())
+		ctx := r.Context()
 		log.InfoContext(ctx, "Received GetUserPR request",
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 		)
 
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			log.WarnContext(ctx, "Missing user_id query parameter")
-			errorhandler.WriteError(w, apperrors.ErrBadRequest, log)
+		query := r.URL.Query()
+		req, err := dto.ParseGetUserPRRequest(query.Get("user_id"), query)
+
+		getUserPR(ctx, w, repos, log, req, err)
+	}
+}
+
+// getUserPR writes a page of the PRs the user reviews. parseErr is the
+// result of parsing the request.
+func getUserPR(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetUserPRRequest, parseErr error) {
+	if parseErr != nil {
+		log.WarnContext(ctx, "Invalid GetUserPR request", slog.Any("error", parseErr))
+		errorhandler.WriteError(ctx, w, parseErr, log)
+
+		return
+	}
+
+	userID := req.UserID
+
+	ctx = logctx.WithUserID(ctx, userID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := user.ReviewFilter{
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Status:        req.Status,
+		AuthorID:      req.AuthorID,
+	}
+
+	prsModel, info, err := repos.User.GetPrUser(ctx, userID, filter,
+		page.Request{Cursor: req.Cursor, Limit: req.Limit, Desc: req.Desc})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get user PRs", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	var prs []dto.PR
+	for _, val := range prsModel {
+		prs = append(prs, dto.PR{
+			ID:       val.ID,
+			AuthorID: val.AuthorID,
+			Name:     val.Name,
+			Status:   val.Status,
+		})
+	}
+
+	response := dto.GetUserPRResponse{
+		UserID: userID,
+		PR:     prs,
+		Pagination: dto.Pagination{
+			NextCursor: info.NextCursor,
+			Limit:      info.Limit,
+			HasMore:    info.HasMore,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode GetUserPR response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User PRs retrieved successfully")
+}
+
+func MoveUserTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received MoveUserTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.MoveUserTeamRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in MoveUserTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		ctx = logctx.WithUserID(ctx, userID)
+		moveUserTeam(ctx, w, repos, log, body)
+	}
+}
+
+func moveUserTeam(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.MoveUserTeamRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid MoveUserTeam request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+	ctx = logctx.WithTeam(ctx, body.TeamName)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	userModel, fromTeam, reassignedModel, err := repos.User.MoveTeam(ctx, body.UserID, body.TeamName, body.Policy)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to move user", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	reassigned := make([]dto.ReassignedReview, 0, len(reassignedModel))
+	for _, val := range reassignedModel {
+		reassigned = append(reassigned, dto.ReassignedReview{
+			PRID:       val.PRID,
+			ReplacedBy: val.ReplacedBy,
+		})
+	}
+
+	response := dto.MoveUserTeamResponse{
+		User: dto.UserWithTeam{
+			UserID:         userModel.ID,
+			Username:       userModel.UserName,
+			IsActive:       userModel.IsActive,
+			TeamName:       userModel.TeamName,
+			MaxOpenReviews: userModel.MaxOpenReviews,
+		},
+		PreviousTeamName: fromTeam,
+		Reassigned:       reassigned,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode MoveUserTeam response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User moved successfully",
+		slog.String("from_team", fromTeam),
+		slog.String("policy", body.Policy),
+		slog.Int("reassigned", len(reassigned)),
+	)
+}
+
+func GetUserHistory(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUserHistory request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getUserHistory(ctx, w, repos, log, r.URL.Query().Get("user_id"))
+	}
+}
+
+func getUserHistory(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	userID string) {
+	req := dto.GetUserHistoryRequest{UserID: userID}
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetUserHistory request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, userID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	historyModel, err := repos.User.GetUserHistory(ctx, userID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get user history", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	history := make([]dto.UserHistoryEntry, 0, len(historyModel))
+	for _, h := range historyModel {
+		history = append(history, dto.UserHistoryEntry{
+			CreatedAt: h.CreatedAt,
+			Event:     h.Event,
+			FromTeam:  h.FromTeam,
+			ToTeam:    h.ToTeam,
+			Policy:    h.Policy,
+		})
+	}
+
+	response := dto.GetUserHistoryResponse{
+		UserID:  userID,
+		History: history,
+	}
 
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-		prsModel, err := repos.User.GetPrUser(ctx, userID)
-		if err != nil {
-			log.ErrorContext(ctx, "Failed to get user PRs", slog.Any("error", err))
-			errorhandler.WriteError(w, err, log)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode GetUserHistory response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User history retrieved successfully")
+}
+
+func DeleteUser(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.DeleteUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in DeleteUser request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
 
 			return
 		}
 
-		var prs []dto.PR
-		for _, val := range prsModel {
-			prs = append(prs, dto.PR{
-				ID:       val.ID,
-				AuthorID: val.AuthorID,
-				Name:     val.Name,
-				Status:   val.Status,
-			})
-		}
+		deleteUser(ctx, w, repos, log, body)
+	}
+}
+
+func deleteUser(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.DeleteUserRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid DeleteUser request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-		response := dto.GetUserPRResponse{
-			UserID: userID,
-			PR:     prs,
+	userModel, reassignedModel, err := repos.User.DeleteUser(ctx, body.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to delete user", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	reassigned := make([]dto.ReassignedReview, 0, len(reassignedModel))
+	for _, val := range reassignedModel {
+		reassigned = append(reassigned, dto.ReassignedReview{
+			PRID:       val.PRID,
+			ReplacedBy: val.ReplacedBy,
+		})
+	}
+
+	response := dto.DeleteUserResponse{
+		User: dto.UserWithTeam{
+			DeletedAt:      userModel.DeletedAt,
+			UserID:         userModel.ID,
+			Username:       userModel.UserName,
+			IsActive:       userModel.IsActive,
+			TeamName:       userModel.TeamName,
+			MaxOpenReviews: userModel.MaxOpenReviews,
+		},
+		Reassigned: reassigned,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode DeleteUser response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User deleted successfully", slog.Int("reassigned", len(reassigned)))
+}
+
+func RestoreUser(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RestoreUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.RestoreUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in RestoreUser request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		restoreUser(ctx, w, repos, log, body)
+	}
+}
+
+func restoreUser(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.RestoreUserRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid RestoreUser request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	userModel, err := repos.User.RestoreUser(ctx, body.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to restore user", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	response := dto.RestoreUserResponse{
+		User: dto.UserWithTeam{
+			UserID:         userModel.ID,
+			Username:       userModel.UserName,
+			IsActive:       userModel.IsActive,
+			TeamName:       userModel.TeamName,
+			MaxOpenReviews: userModel.MaxOpenReviews,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode RestoreUser response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User restored successfully")
+}
+
+func SetUserSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetUserSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.ErrorContext(ctx, "Failed to encode GetUserPR response", slog.Any("error", err))
+		var body dto.SetUserSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetUserSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
 		}
 
-		log.InfoContext(ctx, "User PRs retrieved successfully")
+		setUserSettings(ctx, w, repos, log, body)
+	}
+}
+
+func setUserSettings(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.SetUserSettingsRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetUserSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	userModel, err := repos.User.SetUserSettings(ctx, body.UserID, body.MaxOpenReviews)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to set user settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	response := dto.SetUserSettingsResponse{
+		User: dto.UserWithTeam{
+			UserID:         userModel.ID,
+			Username:       userModel.UserName,
+			IsActive:       userModel.IsActive,
+			TeamName:       userModel.TeamName,
+			MaxOpenReviews: userModel.MaxOpenReviews,
+		},
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode SetUserSettings response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "User settings updated successfully")
 }
@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/user"
+	errorhandler "pr/internal/server/http/error"
+	"time"
+)
+
+func GetNotificationSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetNotificationSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getNotificationSettings(ctx, w, repos, log, dto.GetNotificationSettingsRequest{
+			UserID: r.URL.Query().Get("user_id"),
+		})
+	}
+}
+
+func getNotificationSettings(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.GetNotificationSettingsRequest) {
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetNotificationSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, req.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings, err := repos.User.GetNotificationSettings(ctx, req.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get notification settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeNotificationSettings(ctx, w, log, req.UserID, settings)
+}
+
+func SetNotificationSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetNotificationSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetNotificationSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetNotificationSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		setNotificationSettings(ctx, w, repos, log, body)
+	}
+}
+
+func setNotificationSettings(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.SetNotificationSettingsRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid SetNotificationSettings request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings := user.NotificationSettings{
+		Channels:   body.Channels,
+		Events:     body.Events,
+		Email:      body.Email,
+		WebhookURL: body.WebhookURL,
+		Digest: user.DigestSettings{
+			Enabled:  body.Digest.Enabled,
+			Time:     body.Digest.Time,
+			TimeZone: body.Digest.TimeZone,
+			Days:     body.Digest.Days,
+			Channel:  body.Digest.Channel,
+		},
+	}
+
+	if settings.Digest.Time == "" {
+		settings.Digest.Time = "09:00"
+	}
+
+	if settings.Digest.TimeZone == "" {
+		settings.Digest.TimeZone = "UTC"
+	}
+
+	if settings.Digest.Days == nil {
+		settings.Digest.Days = []int{}
+	}
+
+	if settings.Channels == nil {
+		settings.Channels = []string{}
+	}
+
+	if settings.Events == nil {
+		settings.Events = []string{}
+	}
+
+	if err := repos.User.SetNotificationSettings(ctx, body.UserID, settings); err != nil {
+		log.ErrorContext(ctx, "Failed to set notification settings", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeNotificationSettings(ctx, w, log, body.UserID, settings)
+
+	log.InfoContext(ctx, "Notification settings updated successfully")
+}
+
+func writeNotificationSettings(ctx context.Context, w http.ResponseWriter, log *slog.Logger,
+	userID string, settings user.NotificationSettings) {
+	response := dto.NotificationSettingsResponse{
+		UserID: userID,
+		Settings: dto.NotificationSettings{
+			Channels:   settings.Channels,
+			Events:     settings.Events,
+			Email:      settings.Email,
+			WebhookURL: settings.WebhookURL,
+			Digest: dto.DigestSettings{
+				Enabled:  settings.Digest.Enabled,
+				Time:     settings.Digest.Time,
+				TimeZone: settings.Digest.TimeZone,
+				Days:     settings.Digest.Days,
+				Channel:  settings.Digest.Channel,
+			},
+		},
+		Default: settings.Default,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode NotificationSettings response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,242 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	"pr/internal/repo/unavailability"
+	errorhandler "pr/internal/server/http/error"
+	"time"
+)
+
+func AddUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.CreateUnavailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddUnavailability request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		addUnavailability(ctx, w, repos, log, body)
+	}
+}
+
+func addUnavailability(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.CreateUnavailabilityRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid AddUnavailability request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	window, err := repos.Unavailability.CreateWindow(ctx, unavailability.WindowModel{
+		StartsAt: *body.StartsAt,
+		EndsAt:   *body.EndsAt,
+		UserID:   body.UserID,
+		Reason:   body.Reason,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to add unavailability window", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeWindow(ctx, w, log, http.StatusCreated, window)
+
+	log.InfoContext(ctx, "Unavailability window added successfully", slog.Int64("window_id", window.ID))
+}
+
+func GetUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getUnavailability(ctx, w, repos, log, dto.ListUnavailabilityRequest{UserID: r.URL.Query().Get("user_id")})
+	}
+}
+
+func getUnavailability(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	req dto.ListUnavailabilityRequest) {
+	if err := req.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid GetUnavailability request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, req.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	windowsModel, err := repos.Unavailability.ListWindows(ctx, req.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list unavailability windows", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	windows := make([]dto.UnavailabilityWindow, 0, len(windowsModel))
+	for _, val := range windowsModel {
+		windows = append(windows, toWindowDTO(val))
+	}
+
+	response := dto.ListUnavailabilityResponse{
+		UserID:  req.UserID,
+		Windows: windows,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode GetUnavailability response", slog.Any("error", err))
+	}
+
+	log.InfoContext(ctx, "Unavailability windows retrieved successfully")
+}
+
+func UpdateUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received UpdateUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.UpdateUnavailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in UpdateUnavailability request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		updateUnavailability(ctx, w, repos, log, body)
+	}
+}
+
+func updateUnavailability(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.UpdateUnavailabilityRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid UpdateUnavailability request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	window, err := repos.Unavailability.UpdateWindow(ctx, unavailability.WindowModel{
+		StartsAt: *body.StartsAt,
+		EndsAt:   *body.EndsAt,
+		UserID:   body.UserID,
+		Reason:   body.Reason,
+		ID:       body.ID,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to update unavailability window", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	writeWindow(ctx, w, log, http.StatusOK, window)
+
+	log.InfoContext(ctx, "Unavailability window updated successfully", slog.Int64("window_id", window.ID))
+}
+
+func DeleteUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.DeleteUnavailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in DeleteUnavailability request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		deleteUnavailability(ctx, w, repos, log, body)
+	}
+}
+
+func deleteUnavailability(ctx context.Context, w http.ResponseWriter, repos *repo.Repo, log *slog.Logger,
+	body dto.DeleteUnavailabilityRequest) {
+	if err := body.Validate(); err != nil {
+		log.WarnContext(ctx, "Invalid DeleteUnavailability request", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	ctx = logctx.WithUserID(ctx, body.UserID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := repos.Unavailability.DeleteWindow(ctx, body.UserID, body.ID); err != nil {
+		log.ErrorContext(ctx, "Failed to delete unavailability window", slog.Any("error", err))
+		errorhandler.WriteError(ctx, w, err, log)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.InfoContext(ctx, "Unavailability window deleted successfully", slog.Int64("window_id", body.ID))
+}
+
+func writeWindow(ctx context.Context, w http.ResponseWriter, log *slog.Logger, status int,
+	window unavailability.WindowModel) {
+	response := dto.UnavailabilityResponse{Window: toWindowDTO(window)}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.ErrorContext(ctx, "Failed to encode unavailability response", slog.Any("error", err))
+	}
+}
+
+func toWindowDTO(m unavailability.WindowModel) dto.UnavailabilityWindow {
+	return dto.UnavailabilityWindow{
+		StartsAt: m.StartsAt,
+		EndsAt:   m.EndsAt,
+		UserID:   m.UserID,
+		Reason:   m.Reason,
+		ID:       m.ID,
+	}
+}
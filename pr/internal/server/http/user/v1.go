@@ -0,0 +1,328 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	apperrors "pr/internal/app/errors"
+	"pr/internal/dto"
+	"pr/internal/logctx"
+	"pr/internal/repo"
+	errorhandler "pr/internal/server/http/error"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// GET /api/v1/users/{id}
+func GetUser(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		req := dto.GetUserPRRequest{UserID: chi.URLParam(r, "id")}
+		if err := req.Validate(); err != nil {
+			log.WarnContext(ctx, "Invalid GetUser request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
+
+			return
+		}
+
+		ctx = logctx.WithUserID(ctx, req.UserID)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		user, err := repos.User.GetUser(ctx, req.UserID)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to get user", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, err, log)
+
+			return
+		}
+
+		response := dto.GetUserResponse{
+			User: dto.UserWithTeam{
+				DeletedAt:      user.DeletedAt,
+				UserID:         user.ID,
+				Username:       user.UserName,
+				IsActive:       user.IsActive,
+				TeamName:       user.TeamName,
+				MaxOpenReviews: user.MaxOpenReviews,
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.ErrorContext(ctx, "Failed to encode GetUser response", slog.Any("error", err))
+		}
+
+		log.InfoContext(ctx, "User retrieved successfully")
+	}
+}
+
+// PUT /api/v1/users/{id}
+func PutUser(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received PutUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetUserStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in PutUser request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.ID = chi.URLParam(r, "id")
+
+		setUserStatus(ctx, w, repos, log, body)
+	}
+}
+
+// GET /api/v1/users/{id}/reviews
+func GetUserReviews(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUserReviews request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		req, err := dto.ParseGetUserPRRequest(chi.URLParam(r, "id"), r.URL.Query())
+
+		getUserPR(ctx, w, repos, log, req, err)
+	}
+}
+
+// PUT /api/v1/users/{id}/team
+func PutUserTeam(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received MoveUserTeam request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.MoveUserTeamRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in MoveUserTeam request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.UserID = chi.URLParam(r, "id")
+
+		moveUserTeam(ctx, w, repos, log, body)
+	}
+}
+
+// PUT /api/v1/users/{id}/settings
+func PutUserSettings(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetUserSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetUserSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetUserSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.UserID = chi.URLParam(r, "id")
+
+		setUserSettings(ctx, w, repos, log, body)
+	}
+}
+
+// GET /api/v1/users/{id}/notifications
+func GetUserNotifications(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetNotificationSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getNotificationSettings(ctx, w, repos, log, dto.GetNotificationSettingsRequest{
+			UserID: chi.URLParam(r, "id"),
+		})
+	}
+}
+
+// PUT /api/v1/users/{id}/notifications
+func PutUserNotifications(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received SetNotificationSettings request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.SetNotificationSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in SetNotificationSettings request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.UserID = chi.URLParam(r, "id")
+
+		setNotificationSettings(ctx, w, repos, log, body)
+	}
+}
+
+// GET /api/v1/users/{id}/digest
+func GetUserDigest(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetDigest request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getDigest(ctx, w, repos, log, dto.GetDigestRequest{
+			UserID: chi.URLParam(r, "id"),
+		})
+	}
+}
+
+// GET /api/v1/users/{id}/history
+func GetUserHistoryByID(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUserHistory request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getUserHistory(ctx, w, repos, log, chi.URLParam(r, "id"))
+	}
+}
+
+// DELETE /api/v1/users/{id}
+func DeleteUserByID(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		deleteUser(ctx, w, repos, log, dto.DeleteUserRequest{UserID: chi.URLParam(r, "id")})
+	}
+}
+
+// POST /api/v1/users/{id}/restore
+func RestoreUserByID(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received RestoreUser request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		restoreUser(ctx, w, repos, log, dto.RestoreUserRequest{UserID: chi.URLParam(r, "id")})
+	}
+}
+
+// GET /api/v1/users/{id}/unavailability
+func GetUserUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received GetUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		getUnavailability(ctx, w, repos, log, dto.ListUnavailabilityRequest{UserID: chi.URLParam(r, "id")})
+	}
+}
+
+// POST /api/v1/users/{id}/unavailability
+func PostUserUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received AddUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.CreateUnavailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in AddUnavailability request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.UserID = chi.URLParam(r, "id")
+
+		addUnavailability(ctx, w, repos, log, body)
+	}
+}
+
+// PUT /api/v1/users/{id}/unavailability/{wid}
+func PutUserUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received UpdateUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		var body dto.UpdateUnavailabilityRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			log.WarnContext(ctx, "Invalid JSON in UpdateUnavailability request", slog.Any("error", err))
+			errorhandler.WriteError(ctx, w, apperrors.ErrBadRequest, log)
+
+			return
+		}
+
+		body.UserID = chi.URLParam(r, "id")
+		body.ID = windowIDParam(r)
+
+		updateUnavailability(ctx, w, repos, log, body)
+	}
+}
+
+// DELETE /api/v1/users/{id}/unavailability/{wid}
+func DeleteUserUnavailability(repos *repo.Repo, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log.InfoContext(ctx, "Received DeleteUnavailability request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+
+		deleteUnavailability(ctx, w, repos, log, dto.DeleteUnavailabilityRequest{
+			UserID: chi.URLParam(r, "id"),
+			ID:     windowIDParam(r),
+		})
+	}
+}
+
+// windowIDParam returns 0 for a malformed id, which validation rejects.
+func windowIDParam(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(chi.URLParam(r, "wid"), 10, 64)
+
+	return id
+}
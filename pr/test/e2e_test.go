@@ -8,12 +8,19 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"pr/internal/app"
 	"pr/internal/config"
 	"pr/internal/dto"
+	lockpostgres "pr/internal/repo/lock/postgres"
+	prmodel "pr/internal/repo/pr"
+	prpostgres "pr/internal/repo/pr/postgres"
+	unavailabilitypostgres "pr/internal/repo/unavailability/postgres"
+	userrepo "pr/internal/repo/user"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -44,6 +51,7 @@ func TestMain(m *testing.M) {
 	cfg := config.MustLoad()
 	cfg.Storage.URL = DBURL
 	cfg.HTTP.Addr = HTTPPort
+	cfg.HTTP.AllowMergeOverride = "true"
 
 	go func() {
 		app := app.NewBootstrap(cfg)
@@ -167,11 +175,1216 @@ func TestScenario_CreateTeamsAndPRs(t *testing.T) {
 	prWg.Wait()
 }
 
+func TestScenario_ValidationErrors(t *testing.T) {
+	members := generateUsers(2)
+	members[1].UserID = members[0].UserID
+
+	reqBody, _ := json.Marshal(dto.CreateTeamRequest{TeamName: "", Members: members})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/add")
+	if err != nil || statusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400: status=%d, err=%v", statusCode, err)
+	}
+
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+
+	if resp.Error.Code != "VALIDATION_FAILED" {
+		t.Fatalf("Expected VALIDATION_FAILED, got %s", resp.Error.Code)
+	}
+
+	fields := make(map[string]string)
+	for _, d := range resp.Error.Details {
+		fields[d.Field] = d.Code
+	}
+
+	if fields["team_name"] != "REQUIRED" {
+		t.Errorf("Expected team_name REQUIRED, got %v", resp.Error.Details)
+	}
+
+	if fields["members[1].user_id"] != "DUPLICATE" {
+		t.Errorf("Expected members[1].user_id DUPLICATE, got %v", resp.Error.Details)
+	}
+
+	if resp.RequestID == "" {
+		t.Errorf("Expected request_id in error response")
+	}
+}
+
+func TestScenario_PutUserRequiresIsActive(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	userID := team.Members[0].UserID
+
+	respBody, statusCode, err := Request([]byte(`{}`), http.MethodPut, "/api/v1/users/"+userID)
+	if err != nil || statusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400: status=%d, err=%v", statusCode, err)
+	}
+
+	if fields := errorFields(t, respBody); fields["is_active"] != "REQUIRED" {
+		t.Errorf("Expected is_active REQUIRED, got %v", fields)
+	}
+
+	respBody, statusCode, err = Request(nil, http.MethodGet, "/api/v1/users/"+userID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Get user failed: status=%d, err=%v", statusCode, err)
+	}
+
+	var resp dto.GetUserResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal user response: %v", err)
+	}
+
+	if !resp.User.IsActive {
+		t.Errorf("User must stay active after a rejected update")
+	}
+
+	reqBody, _ := json.Marshal(map[string]string{"user_id": userID})
+
+	respBody, statusCode, err = Request(reqBody, http.MethodPost, "/users/setIsActive")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Legacy setIsActive without is_active must still pass: status=%d, err=%v, body=%s",
+			statusCode, err, respBody)
+	}
+
+	var legacy dto.SetUserStatusResponse
+	if err := json.Unmarshal(respBody, &legacy); err != nil {
+		t.Fatalf("Failed to unmarshal user response: %v", err)
+	}
+
+	if legacy.User.IsActive {
+		t.Errorf("Legacy setIsActive must read a missing is_active as false")
+	}
+}
+
+func TestScenario_LegacyReviewListPages(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	author, reviewer := team.Members[0].UserID, team.Members[1].UserID
+
+	created := make(map[string]bool)
+	for range 3 {
+		resp := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+		created[resp.PR.ID] = true
+	}
+
+	paths := []string{"/users/getReview?user_id=" + reviewer + "&", "/api/v1/users/" + reviewer + "/reviews?"}
+	for _, path := range paths {
+		seen := make(map[string]bool)
+		cursor := ""
+
+		for pages := 1; ; pages++ {
+			respBody, statusCode, err := Request(nil, http.MethodGet, path+"limit=2&cursor="+cursor)
+			if err != nil || statusCode != http.StatusOK {
+				t.Fatalf("Get reviews from %s failed: status=%d, err=%v", path, statusCode, err)
+			}
+
+			var resp dto.GetUserPRResponse
+			if err := json.Unmarshal(respBody, &resp); err != nil {
+				t.Fatalf("Failed to unmarshal reviews response: %v", err)
+			}
+
+			if len(resp.PR) > 2 || resp.Pagination.Limit != 2 {
+				t.Fatalf("%s must honour limit=2, got %s", path, respBody)
+			}
+
+			for _, p := range resp.PR {
+				if seen[p.ID] || !created[p.ID] {
+					t.Fatalf("%s returned %s twice or from another reviewer", path, p.ID)
+				}
+
+				seen[p.ID] = true
+			}
+
+			if !resp.Pagination.HasMore {
+				if pages != 2 {
+					t.Errorf("%s must return 3 PRs in 2 pages, got %d", path, pages)
+				}
+
+				break
+			}
+
+			cursor = resp.Pagination.NextCursor
+		}
+
+		if len(seen) != len(created) {
+			t.Errorf("%s must return all %d PRs across pages, got %v", path, len(created), seen)
+		}
+	}
+}
+
+func TestScenario_RemoveMemberHandsOverReviews(t *testing.T) {
+	team := createTeam(t, generateUsers(4))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) == 0 {
+		t.Fatalf("PR must have a reviewer")
+	}
+
+	removed := created.PR.AssignedReviewers[0]
+
+	respBody, statusCode, err := Request(nil, http.MethodDelete, "/api/v1/teams/"+team.TeamName+"/members/"+author)
+	if err != nil || statusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 removing a PR author: status=%d, err=%v", statusCode, err)
+	}
+
+	if code := errorCode(t, respBody); code != "MEMBERS_HAVE_OPEN_PRS" {
+		t.Errorf("Expected MEMBERS_HAVE_OPEN_PRS, got %s", code)
+	}
+
+	respBody, statusCode, err = Request(nil, http.MethodDelete, "/api/v1/teams/"+team.TeamName+"/members/"+removed)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Remove member failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var resp dto.RemoveTeamMembersResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal remove response: %v", err)
+	}
+
+	if len(resp.Reassigned) != 1 || resp.Reassigned[0].PRID != created.PR.ID ||
+		resp.Reassigned[0].ReplacedBy == "" || resp.Reassigned[0].ReplacedBy == removed {
+		t.Errorf("Expected the review handed over to another member, got %+v", resp.Reassigned)
+	}
+
+	pr := getPR(t, created.PR.ID)
+	for _, id := range pr.AssignedReviewers {
+		if id == removed {
+			t.Errorf("Removed member must no longer review the PR")
+		}
+	}
+
+	respBody, statusCode, err = Request(nil, http.MethodGet, "/api/v1/users/"+removed+"/history")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Get history failed: status=%d, err=%v", statusCode, err)
+	}
+
+	var history dto.GetUserHistoryResponse
+	if err := json.Unmarshal(respBody, &history); err != nil {
+		t.Fatalf("Failed to unmarshal history response: %v", err)
+	}
+
+	if n := len(history.History); n == 0 || history.History[n-1].Event != "TEAM_REMOVED" ||
+		history.History[n-1].FromTeam != team.TeamName {
+		t.Errorf("Expected a TEAM_REMOVED history entry, got %+v", history.History)
+	}
+}
+
+func TestScenario_MoveWithoutCandidateQueuesSlot(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	target := createTeam(t, generateUsers(1))
+	author, reviewer := team.Members[0].UserID, team.Members[1].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+
+	reqBody, _ := json.Marshal(dto.MoveUserTeamRequest{UserID: reviewer, TeamName: target.TeamName, Policy: "reassign"})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/users/moveTeam")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Move must not fail without a candidate: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var resp dto.MoveUserTeamResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal move response: %v", err)
+	}
+
+	if len(resp.Reassigned) != 1 || resp.Reassigned[0].PRID != created.PR.ID || resp.Reassigned[0].ReplacedBy != "" {
+		t.Errorf("Expected the PR reported without a replacement, got %+v", resp.Reassigned)
+	}
+
+	if pr := getPR(t, created.PR.ID); len(pr.AssignedReviewers) != 0 {
+		t.Errorf("Moved reviewer must be unassigned, got %v", pr.AssignedReviewers)
+	}
+}
+
+func TestScenario_UpsertRejectsDeletedUser(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	deleted := team.Members[1]
+
+	respBody, statusCode, err := Request(nil, http.MethodDelete, "/api/v1/users/"+deleted.UserID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Delete user failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	reqBody, _ := json.Marshal(dto.CreateTeamRequest{
+		TeamName: "team-" + uuid.New().String(),
+		Members:  []dto.User{{UserID: deleted.UserID, Username: deleted.Username, IsActive: true}},
+	})
+
+	respBody, statusCode, err = Request(reqBody, http.MethodPost, "/team/add")
+	if err != nil || statusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 adding a deleted user: status=%d, err=%v", statusCode, err)
+	}
+
+	if fields := errorFields(t, respBody); fields["members[0].user_id"] != "DELETED" {
+		t.Errorf("Expected members[0].user_id DELETED, got %v", fields)
+	}
+}
+
+func TestScenario_IdempotentRetryReplaysResponse(t *testing.T) {
+	team := createTeam(t, generateUsers(3))
+	header := http.Header{"Idempotency-Key": {uuid.New().String()}}
+
+	reqBody, _ := json.Marshal(dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: team.Members[0].UserID,
+	})
+
+	first, _, statusCode, err := RequestWithHeaders(reqBody, http.MethodPost, "/pullRequest/create", header)
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create PR failed: status=%d, err=%v, body=%s", statusCode, err, first)
+	}
+
+	retried, resHeader, statusCode, err := RequestWithHeaders(reqBody, http.MethodPost, "/pullRequest/create", header)
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Retry must replay 201 instead of PR_EXISTS: status=%d, err=%v, body=%s", statusCode, err, retried)
+	}
+
+	if resHeader.Get("Idempotent-Replayed") != "true" || !bytes.Equal(first, retried) {
+		t.Errorf("Expected the first response replayed, got %s", retried)
+	}
+
+	reqBody, _ = json.Marshal(dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: team.Members[0].UserID,
+	})
+
+	respBody, _, statusCode, err := RequestWithHeaders(reqBody, http.MethodPost, "/pullRequest/create", header)
+	if err != nil || statusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422 reusing the key with another body: status=%d, err=%v", statusCode, err)
+	}
+
+	if code := errorCode(t, respBody); code != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("Expected IDEMPOTENCY_KEY_REUSED, got %s", code)
+	}
+}
+
+func TestScenario_StaleIfMatchFailsPrecondition(t *testing.T) {
+	team := createTeam(t, generateUsers(4))
+
+	reqBody, _ := json.Marshal(dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: team.Members[0].UserID,
+	})
+
+	respBody, resHeader, statusCode, err := RequestWithHeaders(reqBody, http.MethodPost, "/pullRequest/create", nil)
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var created dto.CreatePRResponse
+	if err := json.Unmarshal(respBody, &created); err != nil {
+		t.Fatalf("Failed to unmarshal PR response: %v", err)
+	}
+
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	// both reassigns send the ETag of the created PR, which the first one
+	// makes stale
+	version := resHeader.Get("ETag")
+	if version == "" {
+		t.Fatalf("Create must return an ETag")
+	}
+
+	swap := func(oldReviewerID string) ([]byte, http.Header, int, error) {
+		reqBody, _ := json.Marshal(dto.SwapPRReviewerRequest{PRID: created.PR.ID, OldUserID: oldReviewerID})
+		return RequestWithHeaders(reqBody, http.MethodPost, "/pullRequest/reassign", http.Header{"If-Match": {version}})
+	}
+
+	respBody, resHeader, statusCode, err = swap(created.PR.AssignedReviewers[0])
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Reassign with a fresh If-Match failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	if fresh := resHeader.Get("ETag"); fresh == "" || fresh == version {
+		t.Errorf("Reassign must return a new ETag, got %q", fresh)
+	}
+
+	respBody, _, statusCode, err = swap(created.PR.AssignedReviewers[1])
+	if err != nil || statusCode != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 with a stale If-Match: status=%d, err=%v", statusCode, err)
+	}
+
+	if code := errorCode(t, respBody); code != "PRECONDITION_FAILED" {
+		t.Errorf("Expected PRECONDITION_FAILED, got %s", code)
+	}
+
+	if pr := getPR(t, created.PR.ID); !slices.Contains(pr.AssignedReviewers, created.PR.AssignedReviewers[1]) {
+		t.Errorf("Rejected reassign must not change the reviewers, got %v", pr.AssignedReviewers)
+	}
+}
+
+func TestScenario_GetAndSearchPRs(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	author, reviewer := team.Members[0].UserID, team.Members[1].UserID
+	token := uuid.New().String()
+
+	open := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "Search " + token, AuthorID: author})
+	merged := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "Search " + token, AuthorID: author})
+
+	reqBody, _ := json.Marshal(dto.MergePRRequest{ID: merged.PR.ID})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/merge")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Merge PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	respBody, statusCode, err = Request(nil, http.MethodGet, "/pullRequest/get?pull_request_id="+open.PR.ID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Get PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var detail dto.GetPRResponse
+	if err := json.Unmarshal(respBody, &detail); err != nil {
+		t.Fatalf("Failed to unmarshal PR response: %v", err)
+	}
+
+	if detail.PR.CreatedAt == nil || detail.PR.MergedAt != nil ||
+		!slices.Equal(detail.PR.AssignedReviewers, []string{reviewer}) {
+		t.Errorf("Unexpected PR detail %+v", detail.PR)
+	}
+
+	if len(detail.PR.History) == 0 || detail.PR.History[0].Event != "CREATED" {
+		t.Errorf("Expected the history to start with CREATED, got %+v", detail.PR.History)
+	}
+
+	respBody, statusCode, err = Request(nil, http.MethodGet, "/pullRequest/list?status=OPEN&team_name="+team.TeamName+
+		"&reviewer_id="+reviewer+"&q="+token)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("List PRs failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var list dto.ListPRsResponse
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		t.Fatalf("Failed to unmarshal list response: %v", err)
+	}
+
+	if len(list.PullRequests) != 1 || list.PullRequests[0].ID != open.PR.ID {
+		t.Errorf("Expected only the open PR, got %s", respBody)
+	}
+}
+
+func TestScenario_UnavailableReviewerIsSkippedAndHandedOver(t *testing.T) {
+	team := createTeam(t, generateUsers(4))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	away := created.PR.AssignedReviewers[0]
+	addUnavailability(t, away, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+
+	// the handover job is off in the test config, so it is run by hand
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, DBURL)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	handovers, err := unavailabilitypostgres.NewUnavailabilityPostgres(pool, slog.Default(),
+		prmodel.AssignmentPolicy{}).HandOverStarted(ctx)
+	if err != nil {
+		t.Fatalf("Handover failed: %v", err)
+	}
+
+	var reassigned []userrepo.ReassignedReview
+	for _, h := range handovers {
+		if h.UserID == away {
+			reassigned = h.Reassigned
+		}
+	}
+
+	if len(reassigned) != 1 || reassigned[0].PRID != created.PR.ID || reassigned[0].ReplacedBy == "" {
+		t.Fatalf("Expected the review handed over, got %+v", reassigned)
+	}
+
+	if pr := getPR(t, created.PR.ID); slices.Contains(pr.AssignedReviewers, away) {
+		t.Errorf("Unavailable reviewer must be replaced, got %v", pr.AssignedReviewers)
+	}
+
+	next := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(next.PR.AssignedReviewers) != 2 || slices.Contains(next.PR.AssignedReviewers, away) {
+		t.Errorf("Expected the two available members assigned, got %v", next.PR.AssignedReviewers)
+	}
+}
+
+func TestScenario_ReviewerAtCapacity(t *testing.T) {
+	limit := 1
+	members := generateUsers(2)
+	members[1].MaxOpenReviews = &limit
+	team := createTeam(t, members)
+	author, reviewer := team.Members[0].UserID, team.Members[1].UserID
+
+	first := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if !slices.Equal(first.PR.AssignedReviewers, []string{reviewer}) {
+		t.Fatalf("Expected %s assigned, got %v", reviewer, first.PR.AssignedReviewers)
+	}
+
+	reqBody, _ := json.Marshal(dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/create")
+	if err != nil || statusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 with everyone at capacity: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	if code := errorCode(t, respBody); code != "NO_CAPACITY" {
+		t.Errorf("Expected NO_CAPACITY, got %s", code)
+	}
+
+	// the test server runs without EXCEED_CAPACITY, so the fallback is
+	// checked on the repo
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, DBURL)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	prs := prpostgres.NewPrPostgres(pool, slog.Default(), prmodel.AssignmentPolicy{ExceedCapacity: true})
+
+	_, res, err := prs.CreatePR(ctx, prmodel.PRModel{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author, Status: "OPEN",
+	})
+	if err != nil {
+		t.Fatalf("Create PR exceeding capacity failed: %v", err)
+	}
+
+	if !slices.Equal(res.Reviewers, []string{reviewer}) || !res.CapacityExceeded {
+		t.Errorf("Expected %s assigned beyond capacity, got %+v", reviewer, res)
+	}
+}
+
+func TestScenario_FallbackTeamsFillInOrder(t *testing.T) {
+	members := generateUsers(3)
+	members[2].IsActive = false
+	team := createTeam(t, members)
+	first := createTeam(t, generateUsers(1))
+	second := createTeam(t, generateUsers(2))
+
+	setTeamSettings(t, team.TeamName, dto.TeamSettings{FallbackTeams: []string{first.TeamName, second.TeamName}})
+
+	created := createPR(t, dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: team.Members[0].UserID,
+	})
+
+	// the own team goes first, then the fallbacks in their order
+	teammate, fallback := team.Members[1].UserID, first.Members[0].UserID
+
+	if len(created.PR.AssignedReviewers) != 2 || !slices.Contains(created.PR.AssignedReviewers, teammate) ||
+		!slices.Contains(created.PR.AssignedReviewers, fallback) {
+		t.Errorf("Expected %s and %s assigned, got %v", teammate, fallback, created.PR.AssignedReviewers)
+	}
+
+	if !slices.Equal(created.FallbackReviewers, []string{fallback}) {
+		t.Errorf("Expected fallback_reviewers [%s], got %v", fallback, created.FallbackReviewers)
+	}
+}
+
+func TestScenario_UnfilledSlotsAreQueuedOrRejected(t *testing.T) {
+	team := createTeam(t, generateUsers(2))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) != 1 || created.UnfilledSlots != 1 {
+		t.Fatalf("Expected one reviewer and one unfilled slot, got %+v", created)
+	}
+
+	joined := generateUsers(1)
+	reqBody, _ := json.Marshal(dto.CreateTeamRequest{TeamName: team.TeamName, Members: joined})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/addMembers")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Add members failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	if pr := getPR(t, created.PR.ID); !slices.Contains(pr.AssignedReviewers, joined[0].UserID) {
+		t.Errorf("The joined member must take the open slot, got %v", pr.AssignedReviewers)
+	}
+
+	strict := createTeam(t, generateUsers(2))
+	setTeamSettings(t, strict.TeamName, dto.TeamSettings{ReviewerPolicy: "require_full"})
+
+	reqBody, _ = json.Marshal(dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: strict.Members[0].UserID,
+	})
+
+	respBody, statusCode, err = Request(reqBody, http.MethodPost, "/pullRequest/create")
+	if err != nil || statusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 under require_full: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	if code := errorCode(t, respBody); code != "NOT_ENOUGH_REVIEWERS" {
+		t.Errorf("Expected NOT_ENOUGH_REVIEWERS, got %s", code)
+	}
+}
+
+func TestScenario_AddAndRemoveReviewersByHand(t *testing.T) {
+	team := createTeam(t, generateUsers(3))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	dropped, kept := created.PR.AssignedReviewers[0], created.PR.AssignedReviewers[1]
+
+	change := func(path, userID string) ([]byte, int) {
+		t.Helper()
+
+		reqBody, _ := json.Marshal(dto.ChangeReviewerRequest{PRID: created.PR.ID, UserID: userID})
+
+		respBody, statusCode, err := Request(reqBody, http.MethodPost, path)
+		if err != nil {
+			t.Fatalf("Request to %s failed: %v", path, err)
+		}
+
+		return respBody, statusCode
+	}
+
+	if respBody, statusCode := change("/pullRequest/reviewers/remove", dropped); statusCode != http.StatusOK {
+		t.Fatalf("Remove reviewer failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if pr := getPR(t, created.PR.ID); !slices.Equal(pr.AssignedReviewers, []string{kept}) {
+		t.Errorf("Expected only %s left, got %v", kept, pr.AssignedReviewers)
+	}
+
+	respBody, statusCode := change("/pullRequest/reviewers/add", author)
+	if statusCode != http.StatusConflict || errorCode(t, respBody) != "REVIEWER_IS_AUTHOR" {
+		t.Errorf("Expected 409 REVIEWER_IS_AUTHOR adding the author: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if respBody, statusCode := change("/pullRequest/reviewers/add", dropped); statusCode != http.StatusOK {
+		t.Fatalf("Add reviewer failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	reqBody, _ := json.Marshal(dto.MergePRRequest{ID: created.PR.ID})
+	if respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/merge"); err != nil ||
+		statusCode != http.StatusOK {
+		t.Fatalf("Merge PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	respBody, statusCode = change("/pullRequest/reviewers/remove", dropped)
+	if statusCode != http.StatusConflict || errorCode(t, respBody) != "PR_MERGED" {
+		t.Errorf("Expected 409 PR_MERGED after merge: status=%d, body=%s", statusCode, respBody)
+	}
+}
+
+func TestScenario_ManualChangesKeepCapacityAndRoles(t *testing.T) {
+	limit := 1
+	members := generateUsers(3)
+	members[1].MaxOpenReviews = &limit
+	createTeam(t, members)
+	author, busy := members[0].UserID, members[1].UserID
+
+	createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+
+	// busy reviews the first PR, so the second one leaves a slot for them
+	second := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if slices.Contains(second.PR.AssignedReviewers, busy) {
+		t.Fatalf("A reviewer at capacity must not be picked, got %v", second.PR.AssignedReviewers)
+	}
+
+	reqBody, _ := json.Marshal(dto.ChangeReviewerRequest{PRID: second.PR.ID, UserID: busy})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reviewers/add")
+	if err != nil || statusCode != http.StatusConflict || errorCode(t, respBody) != "NO_CAPACITY" {
+		t.Errorf("Expected 409 NO_CAPACITY adding a busy reviewer: status=%d, err=%v, body=%s",
+			statusCode, err, respBody)
+	}
+
+	members = generateUsers(3)
+	members[1].Role = "senior"
+	members[2].Role = "junior"
+	team := createTeam(t, members)
+	setTeamSettings(t, team.TeamName, dto.TeamSettings{RoleRule: dto.RoleRule{MinReviewers: 1, MinRole: "senior"}})
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: members[0].UserID})
+
+	reqBody, _ = json.Marshal(dto.ChangeReviewerRequest{PRID: created.PR.ID, UserID: members[1].UserID})
+
+	respBody, statusCode, err = Request(reqBody, http.MethodPost, "/pullRequest/reviewers/remove")
+	if err != nil || statusCode != http.StatusConflict || errorCode(t, respBody) != "ROLE_RULE_UNSATISFIED" {
+		t.Errorf("Expected 409 ROLE_RULE_UNSATISFIED removing the only senior: status=%d, err=%v, body=%s",
+			statusCode, err, respBody)
+	}
+
+	reqBody, _ = json.Marshal(dto.ChangeReviewerRequest{PRID: created.PR.ID, UserID: members[2].UserID})
+
+	if respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reviewers/remove"); err != nil ||
+		statusCode != http.StatusOK {
+		t.Errorf("Removing the junior must pass: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+}
+
+func TestScenario_ReassignToTargetOrWithExclusions(t *testing.T) {
+	team := createTeam(t, generateUsers(5))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	first, second := created.PR.AssignedReviewers[0], created.PR.AssignedReviewers[1]
+
+	var spare []string
+	for _, m := range team.Members[1:] {
+		if m.UserID != first && m.UserID != second {
+			spare = append(spare, m.UserID)
+		}
+	}
+
+	swap := func(req dto.SwapPRReviewerRequest) ([]byte, int) {
+		t.Helper()
+
+		req.PRID = created.PR.ID
+		reqBody, _ := json.Marshal(req)
+
+		respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reassign")
+		if err != nil {
+			t.Fatalf("Reassign failed: %v", err)
+		}
+
+		return respBody, statusCode
+	}
+
+	for target, code := range map[string]string{author: "REVIEWER_IS_AUTHOR", second: "ALREADY_ASSIGNED"} {
+		respBody, statusCode := swap(dto.SwapPRReviewerRequest{OldUserID: first, NewReviewerID: target})
+		if statusCode != http.StatusConflict || errorCode(t, respBody) != code {
+			t.Errorf("Expected 409 %s for target %s: status=%d, body=%s", code, target, statusCode, respBody)
+		}
+	}
+
+	respBody, statusCode := swap(dto.SwapPRReviewerRequest{OldUserID: first, NewReviewerID: spare[0], Reason: "busy"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("Reassign to a target failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	var resp dto.SwapPRReviewerResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal reassign response: %v", err)
+	}
+
+	if resp.ReplacedBy != spare[0] {
+		t.Errorf("Expected %s picked, got %s", spare[0], resp.ReplacedBy)
+	}
+
+	// first is free again, so excluding them leaves only the last spare
+	respBody, statusCode = swap(dto.SwapPRReviewerRequest{OldUserID: second, Exclude: []string{first}})
+	if statusCode != http.StatusOK {
+		t.Fatalf("Reassign with exclusions failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal reassign response: %v", err)
+	}
+
+	if resp.ReplacedBy != spare[1] {
+		t.Errorf("Expected %s picked, got %s", spare[1], resp.ReplacedBy)
+	}
+
+	history := getPR(t, created.PR.ID).History
+	if !slices.ContainsFunc(history, func(e dto.PRHistoryEntry) bool {
+		return e.Event == "REVIEWER_REASSIGNED" && e.OldUserID == first && e.UserID == spare[0] && e.Reason == "busy"
+	}) {
+		t.Errorf("Expected the reason in the PR history, got %+v", history)
+	}
+}
+
+func TestScenario_DeclineReassignsAndExcludesDecliner(t *testing.T) {
+	team := createTeam(t, generateUsers(4))
+	author := team.Members[0].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author})
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	decliner, accepter := created.PR.AssignedReviewers[0], created.PR.AssignedReviewers[1]
+
+	respBody, statusCode := setReviewState(t, created.PR.ID, accepter, "ACCEPTED")
+	if statusCode != http.StatusOK {
+		t.Fatalf("Accept failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	respBody, statusCode = setReviewState(t, created.PR.ID, decliner, "DECLINED")
+	if statusCode != http.StatusOK {
+		t.Fatalf("Decline failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	var declined dto.SetReviewStateResponse
+	if err := json.Unmarshal(respBody, &declined); err != nil {
+		t.Fatalf("Failed to unmarshal state response: %v", err)
+	}
+
+	if declined.ReplacedBy == "" || declined.ReplacedBy == decliner || declined.ReplacedBy == accepter {
+		t.Fatalf("Expected the last member picked for the declined review, got %+v", declined)
+	}
+
+	pr := getPR(t, created.PR.ID)
+	if !slices.ContainsFunc(pr.Reviews, func(r dto.PRReview) bool {
+		return r.UserID == accepter && r.State == "ACCEPTED"
+	}) {
+		t.Errorf("Expected %s ACCEPTED, got %+v", accepter, pr.Reviews)
+	}
+
+	// the decliner is the only one left and must not come back
+	reqBody, _ := json.Marshal(dto.SwapPRReviewerRequest{PRID: created.PR.ID, OldUserID: declined.ReplacedBy})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reassign")
+	if err != nil || statusCode != http.StatusConflict || errorCode(t, respBody) != "NO_CANDIDATE" {
+		t.Errorf("Expected 409 NO_CANDIDATE: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	reqBody, _ = json.Marshal(dto.ChangeReviewerRequest{PRID: created.PR.ID, UserID: decliner})
+
+	respBody, statusCode, err = Request(reqBody, http.MethodPost, "/pullRequest/reviewers/add")
+	if err != nil || statusCode != http.StatusConflict || errorCode(t, respBody) != "REVIEWER_DECLINED" {
+		t.Errorf("Expected 409 REVIEWER_DECLINED: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+}
+
+func TestScenario_MergePolicyBlocksUntilOverride(t *testing.T) {
+	team := createTeam(t, generateUsers(3))
+	setTeamSettings(t, team.TeamName, dto.TeamSettings{
+		MergePolicy: dto.MergePolicy{MinApprovals: 1, BlockOnChangesRequested: true},
+	})
+
+	created := createPR(t, dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: team.Members[0].UserID,
+	})
+	if len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("PR must have 2 reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	merge := func(override bool) ([]byte, int) {
+		t.Helper()
+
+		reqBody, _ := json.Marshal(dto.MergePRRequest{ID: created.PR.ID, Override: override})
+
+		respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/merge")
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+
+		return respBody, statusCode
+	}
+
+	respBody, statusCode := merge(false)
+	if statusCode != http.StatusConflict || errorCode(t, respBody) != "MERGE_BLOCKED" {
+		t.Fatalf("Expected 409 MERGE_BLOCKED without approvals: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if fields := errorFields(t, respBody); fields["min_approvals"] != "UNMET" || len(fields) != 1 {
+		t.Errorf("Expected only min_approvals unmet, got %v", fields)
+	}
+
+	for state, reviewer := range map[string]string{
+		"APPROVED":          created.PR.AssignedReviewers[0],
+		"CHANGES_REQUESTED": created.PR.AssignedReviewers[1],
+	} {
+		if respBody, statusCode := setReviewState(t, created.PR.ID, reviewer, state); statusCode != http.StatusOK {
+			t.Fatalf("Set %s failed: status=%d, body=%s", state, statusCode, respBody)
+		}
+	}
+
+	respBody, statusCode = merge(false)
+	if statusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 with changes requested: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if fields := errorFields(t, respBody); fields["no_changes_requested"] != "UNMET" || len(fields) != 1 {
+		t.Errorf("Expected only no_changes_requested unmet, got %v", fields)
+	}
+
+	if respBody, statusCode = merge(true); statusCode != http.StatusOK {
+		t.Fatalf("Override merge failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	if pr := getPR(t, created.PR.ID); pr.Status != "MERGED" {
+		t.Errorf("Expected the PR merged, got %s", pr.Status)
+	}
+}
+
+func TestScenario_LabelsRequireOrPreferSkills(t *testing.T) {
+	required, preferred, missing := createTag(t, "require"), createTag(t, "prefer"), createTag(t, "require")
+
+	members := generateUsers(5)
+	members[1].Skills = []string{required}
+	members[2].Skills = []string{preferred}
+	team := createTeam(t, members)
+	author := members[0].UserID
+
+	// the required skill takes one slot and the preferred one outranks the
+	// members without skills for the other
+	created := createPR(t, dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author, Labels: []string{preferred, required},
+	})
+
+	if len(created.PR.AssignedReviewers) != 2 || !slices.Contains(created.PR.AssignedReviewers, members[1].UserID) ||
+		!slices.Contains(created.PR.AssignedReviewers, members[2].UserID) {
+		t.Errorf("Expected both skilled members of %s assigned, got %v", team.TeamName, created.PR.AssignedReviewers)
+	}
+
+	if !slices.Equal(created.SkillReviewers, []string{members[1].UserID}) {
+		t.Errorf("Expected skill_reviewers [%s], got %v", members[1].UserID, created.SkillReviewers)
+	}
+
+	reqBody, _ := json.Marshal(dto.CreatePRRequest{
+		ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: author, Labels: []string{missing},
+	})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/create")
+	if err != nil || statusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 without a required skill: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	if code := errorCode(t, respBody); code != "REQUIRED_SKILL_UNAVAILABLE" {
+		t.Errorf("Expected REQUIRED_SKILL_UNAVAILABLE, got %s", code)
+	}
+
+	if fields := errorFields(t, respBody); fields["labels[0]"] != "NO_REVIEWER" {
+		t.Errorf("Expected labels[0] NO_REVIEWER, got %v", fields)
+	}
+}
+
+func TestScenario_RoleRuleOnCreateAndSwap(t *testing.T) {
+	members := generateUsers(4)
+	members[1].Role = "senior"
+	members[2].Role = "junior"
+	members[3].Role = "junior"
+	team := createTeam(t, members)
+	setTeamSettings(t, team.TeamName, dto.TeamSettings{RoleRule: dto.RoleRule{MinReviewers: 1, MinRole: "senior"}})
+
+	senior := members[1].UserID
+
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: members[0].UserID})
+	if len(created.PR.AssignedReviewers) != 2 || !slices.Contains(created.PR.AssignedReviewers, senior) {
+		t.Fatalf("Expected the senior among the reviewers, got %v", created.PR.AssignedReviewers)
+	}
+
+	swap := func() ([]byte, int) {
+		t.Helper()
+
+		reqBody, _ := json.Marshal(dto.SwapPRReviewerRequest{PRID: created.PR.ID, OldUserID: senior})
+
+		respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reassign")
+		if err != nil {
+			t.Fatalf("Reassign failed: %v", err)
+		}
+
+		return respBody, statusCode
+	}
+
+	// the spare junior can't replace the only senior
+	respBody, statusCode := swap()
+	if statusCode != http.StatusConflict || errorCode(t, respBody) != "ROLE_RULE_UNSATISFIED" {
+		t.Fatalf("Expected 409 ROLE_RULE_UNSATISFIED: status=%d, body=%s", statusCode, respBody)
+	}
+
+	lead := generateUsers(1)
+	lead[0].Role = "lead"
+	reqBody, _ := json.Marshal(dto.CreateTeamRequest{TeamName: team.TeamName, Members: lead})
+
+	if respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/addMembers"); err != nil ||
+		statusCode != http.StatusOK {
+		t.Fatalf("Add members failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	respBody, statusCode = swap()
+	if statusCode != http.StatusOK {
+		t.Fatalf("Reassign to a lead failed: status=%d, body=%s", statusCode, respBody)
+	}
+
+	var resp dto.SwapPRReviewerResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal reassign response: %v", err)
+	}
+
+	if resp.ReplacedBy != lead[0].UserID {
+		t.Errorf("Expected the lead to replace the senior, got %s", resp.ReplacedBy)
+	}
+}
+
+func TestScenario_RoleRuleOnFill(t *testing.T) {
+	members := generateUsers(2)
+	members[1].Role = "junior"
+	team := createTeam(t, members)
+
+	// the PR is queued before the rule exists, so the filler has to keep
+	// its open slot for a senior
+	created := createPR(t, dto.CreatePRRequest{ID: uuid.New().String(), Name: "E2E Test PR", AuthorID: members[0].UserID})
+	if created.UnfilledSlots != 1 {
+		t.Fatalf("Expected one unfilled slot, got %+v", created)
+	}
+
+	setTeamSettings(t, team.TeamName, dto.TeamSettings{RoleRule: dto.RoleRule{MinReviewers: 1, MinRole: "senior"}})
+
+	join := func(role string) string {
+		t.Helper()
+
+		joined := generateUsers(1)
+		joined[0].Role = role
+		reqBody, _ := json.Marshal(dto.CreateTeamRequest{TeamName: team.TeamName, Members: joined})
+
+		respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/addMembers")
+		if err != nil || statusCode != http.StatusOK {
+			t.Fatalf("Add members failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+		}
+
+		return joined[0].UserID
+	}
+
+	junior := join("junior")
+	if pr := getPR(t, created.PR.ID); slices.Contains(pr.AssignedReviewers, junior) {
+		t.Errorf("A junior must not take the slot the rule needs, got %v", pr.AssignedReviewers)
+	}
+
+	senior := join("senior")
+	if pr := getPR(t, created.PR.ID); !slices.Contains(pr.AssignedReviewers, senior) {
+		t.Errorf("The senior must take the open slot, got %v", pr.AssignedReviewers)
+	}
+}
+
+// TestScenario_JobLocksOnSmallPool runs more locked jobs at once than the
+// pool has connections; each job needs a pooled connection of its own.
+func TestScenario_JobLocksOnSmallPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, DBURL+"&pool_max_conns=1")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	locks := lockpostgres.NewLockPostgres(pool, slog.Default())
+	defer func() { _ = locks.Close(ctx) }()
+
+	var wg sync.WaitGroup
+
+	for i := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			locked, err := locks.WithLock(ctx, fmt.Sprintf("e2e-job-%d", i), func(ctx context.Context) {
+				var one int
+				if err := pool.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+					t.Errorf("Job %d query failed: %v", i, err)
+				}
+			})
+			if err != nil || !locked {
+				t.Errorf("Job %d must run: locked=%v, err=%v", i, locked, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// TestScenario_JobLockIsExclusiveAcrossInstances checks that a job locked
+// by one instance is skipped by another.
+func TestScenario_JobLockIsExclusiveAcrossInstances(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, DBURL)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	first := lockpostgres.NewLockPostgres(pool, slog.Default())
+	defer func() { _ = first.Close(ctx) }()
+
+	second := lockpostgres.NewLockPostgres(pool, slog.Default())
+	defer func() { _ = second.Close(ctx) }()
+
+	var ran atomic.Int32
+
+	locked, err := first.WithLock(ctx, "e2e-exclusive", func(ctx context.Context) {
+		ran.Add(1)
+
+		locked, err := second.WithLock(ctx, "e2e-exclusive", func(context.Context) { ran.Add(1) })
+		if err != nil || locked {
+			t.Errorf("Second instance must skip the locked job: locked=%v, err=%v", locked, err)
+		}
+	})
+	if err != nil || !locked {
+		t.Fatalf("First instance must run the job: locked=%v, err=%v", locked, err)
+	}
+
+	if ran.Load() != 1 {
+		t.Errorf("Job must run once, ran %d times", ran.Load())
+	}
+
+	locked, err = second.WithLock(ctx, "e2e-exclusive", func(context.Context) {})
+	if err != nil || !locked {
+		t.Errorf("Released lock must be free again: locked=%v, err=%v", locked, err)
+	}
+}
+
 // =================================================================
 // =================================================================
 
 var counter int64
 
+// getPR fetches a PR and fails the test if it can't.
+func getPR(t *testing.T, id string) dto.PRDetails {
+	t.Helper()
+
+	respBody, statusCode, err := Request(nil, http.MethodGet, "/api/v1/pull-requests/"+id)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Get PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var resp dto.GetPRResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal PR response: %v", err)
+	}
+
+	return resp.PR
+}
+
+// addUnavailability adds an unavailability window and fails the test if
+// it can't.
+func addUnavailability(t *testing.T, userID string, startsAt, endsAt time.Time) {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(dto.CreateUnavailabilityRequest{
+		UserID: userID, StartsAt: &startsAt, EndsAt: &endsAt, Reason: "vacation",
+	})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/users/addUnavailability")
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Add unavailability failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+}
+
+// createPR creates a PR and fails the test if it can't.
+func createPR(t *testing.T, req dto.CreatePRRequest) dto.CreatePRResponse {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(req)
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/create")
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create PR failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var resp dto.CreatePRResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal PR response: %v", err)
+	}
+
+	return resp
+}
+
+// createTag creates a tag with a unique name and the given mode and fails
+// the test if it can't.
+func createTag(t *testing.T, mode string) string {
+	t.Helper()
+
+	name := "tag-" + uuid.New().String()
+	reqBody, _ := json.Marshal(dto.CreateTagRequest{Name: name, Mode: mode})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/tags/create")
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create tag failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	return name
+}
+
+// createTeam creates a team with a unique name and fails the test if it
+// can't.
+func createTeam(t *testing.T, members []dto.User) dto.Team {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(dto.CreateTeamRequest{TeamName: "team-" + uuid.New().String(), Members: members})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/add")
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create team failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+
+	var resp dto.CreateTeamResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal team response: %v", err)
+	}
+
+	return resp.Team
+}
+
+// setReviewState sets the state of a review and returns the response.
+func setReviewState(t *testing.T, prID, userID, state string) ([]byte, int) {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(dto.SetReviewStateRequest{PRID: prID, UserID: userID, State: state})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/pullRequest/reviewers/setState")
+	if err != nil {
+		t.Fatalf("Set review state failed: %v", err)
+	}
+
+	return respBody, statusCode
+}
+
+// setTeamSettings replaces the settings of a team and fails the test if
+// it can't.
+func setTeamSettings(t *testing.T, teamName string, settings dto.TeamSettings) {
+	t.Helper()
+
+	reqBody, _ := json.Marshal(dto.SetTeamSettingsRequest{TeamName: teamName, TeamSettings: settings})
+
+	respBody, statusCode, err := Request(reqBody, http.MethodPost, "/team/setSettings")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Set team settings failed: status=%d, err=%v, body=%s", statusCode, err, respBody)
+	}
+}
+
+// errorFields maps the fields of a VALIDATION_FAILED, MERGE_BLOCKED or
+// REQUIRED_SKILL_UNAVAILABLE response to their codes.
+func errorFields(t *testing.T, respBody []byte) map[string]string {
+	t.Helper()
+
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+
+	fields := make(map[string]string)
+	for _, d := range resp.Error.Details {
+		fields[d.Field] = d.Code
+	}
+
+	return fields
+}
+
+// errorCode returns the code of an error response.
+func errorCode(t *testing.T, respBody []byte) string {
+	t.Helper()
+
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+
+	return resp.Error.Code
+}
+
 func generateUsers(n int) []dto.User {
 	users := make([]dto.User, n)
 	for i := 0; i < n; i++ {
@@ -187,9 +1400,21 @@ func generateUsers(n int) []dto.User {
 }
 
 func Request(reqBody []byte, method, path string) (resBody []byte, statusCode int, err error) {
+	resBody, _, statusCode, err = RequestWithHeaders(reqBody, method, path, nil)
+	return resBody, statusCode, err
+}
+
+// RequestWithHeaders sends the request with extra headers and also returns
+// the response headers.
+func RequestWithHeaders(reqBody []byte, method, path string,
+	header http.Header) (resBody []byte, resHeader http.Header, statusCode int, err error) {
 	req, err := http.NewRequest(method, HTTPHost+path, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -200,14 +1425,14 @@ func Request(reqBody []byte, method, path string) (resBody []byte, statusCode in
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to send request: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+		return nil, resp.Header, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return body, resp.StatusCode, nil
+	return body, resp.Header, resp.StatusCode, nil
 }